@@ -0,0 +1,154 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// AttachDb - Attach the database file at path to every connection under the alias, so its
+// tables can be used as alias.table. The file is created if it does not exist. Databases
+// cannot be attached while a transaction is open. Connections in use by other goroutines
+// are not changed while they are in use, and are replaced by new connections with the
+// database attached when they are done.
+func (sdb *SQLDb) AttachDb(path, alias string) error {
+	stmt := fmt.Sprintf("ATTACH DATABASE ? AS %s", QuoteIdentifier(alias))
+	attach := func(conn *sqlite3.SQLiteConn) error {
+		_, err := conn.Exec(stmt, []driver.Value{path})
+		return err
+	}
+	sdb.conns.resetSetup("attach:"+alias, attach)
+	if err := sdb.applyPoolSetup(attach); err != nil {
+		// Replace any connections that were attached, too.
+		sdb.conns.resetSetup("attach:"+alias, nil)
+		return fmt.Errorf("dberror: attaching %s as %s: %v", path, alias, err)
+	}
+	return nil
+}
+
+// DetachDb - Detach the database attached under the alias from every connection.
+// Like AttachDb, connections in use by other goroutines are replaced when they are done.
+func (sdb *SQLDb) DetachDb(alias string) error {
+	stmt := fmt.Sprintf("DETACH DATABASE %s", QuoteIdentifier(alias))
+	if !sdb.conns.resetSetup("attach:"+alias, nil) {
+		return fmt.Errorf("dberror: no database is attached as %s", alias)
+	}
+	err := sdb.applyPoolSetup(func(conn *sqlite3.SQLiteConn) error {
+		_, err := conn.Exec(stmt, nil)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("dberror: detaching %s: %v", alias, err)
+	}
+	return nil
+}
+
+// applyPoolSetup applies a setup change made with resetSetup to the connections that are held
+// rather than returned to the pool: the connection of a bound handle, and the writer connection.
+// Without either, it opens a connection with the new setup, which reports any error in the setup.
+func (sdb *SQLDb) applyPoolSetup(apply func(conn *sqlite3.SQLiteConn) error) error {
+	raw := func(conn *sql.Conn) error {
+		return conn.Raw(func(driverConn interface{}) error {
+			return apply(sqliteConn(driverConn))
+		})
+	}
+	switch {
+	case sdb.conn != nil:
+		return raw(sdb.conn)
+	case sdb.writer != nil:
+		return sdb.writer.do(raw)
+	}
+	conn, err := sdb.DB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// CopyTable - Copy the rows of the source table into the destination table, either of which
+// may be qualified with the alias of an attached database, as in "archive.orders". If the
+// destination table does not exist, it is created with the definition of the source table.
+// Indexes and triggers are not copied.
+func (sdb *SQLDb) CopyTable(src, dest string) error {
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		_, exists, err := tx.tableSQL(dest)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			def, ok, err := tx.tableSQL(src)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("dberror: no such table: %s", src)
+			}
+			create, err := renameCreateTable(def, quoteQualified(dest))
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(create); err != nil {
+				return err
+			}
+		}
+		return tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", quoteQualified(dest), quoteQualified(src)))
+	})
+}
+
+// QuoteIdentifier - Quote the name for use as an SQL identifier, such as a table, column, or alias.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteQualified quotes a table name that may be qualified with a database alias.
+func quoteQualified(name string) string {
+	if alias, table, ok := strings.Cut(name, "."); ok {
+		return QuoteIdentifier(alias) + "." + QuoteIdentifier(table)
+	}
+	return QuoteIdentifier(name)
+}
+
+// tableSQL returns the definition of a table that may be qualified with a database alias.
+func (sdb *SQLDb) tableSQL(name string) (string, bool, error) {
+	schema := "main"
+	table := name
+	if alias, t, ok := strings.Cut(name, "."); ok {
+		schema, table = alias, t
+	}
+	var def string
+	err := sdb.SingleQueryArgs(fmt.Sprintf("SELECT sql FROM %s.sqlite_master WHERE type = 'table' AND name = ?",
+		QuoteIdentifier(schema)), []interface{}{table}, &def)
+	if err != nil {
+		if errors.Is(err, ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return def, true, nil
+}
+
+// renameCreateTable replaces the table name in a CREATE TABLE statement.
+func renameCreateTable(def, name string) (string, error) {
+	const prefix = "CREATE TABLE "
+	if !strings.HasPrefix(def, prefix) {
+		return "", fmt.Errorf("dberror: unexpected table definition: %s", def)
+	}
+	rest := strings.TrimLeft(def[len(prefix):], " ")
+	// Skip the original name, which may be quoted.
+	end := 0
+	switch rest[0] {
+	case '"', '`', '[', '\'':
+		end = quoteEnd(rest, 0)
+	default:
+		end = strings.IndexAny(rest, " (")
+		if end < 0 {
+			return "", fmt.Errorf("dberror: unexpected table definition: %s", def)
+		}
+	}
+	return prefix + name + rest[end:], nil
+}
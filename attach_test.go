@@ -0,0 +1,132 @@
+package sqldb
+
+import (
+	"os"
+	"testing"
+)
+
+const testArchiveDbName = testDbName + "Archive"
+
+func TestAttachDb(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer removeDbFiles(testArchiveDbName)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable(`orders (id INTEGER PRIMARY KEY, "item name" TEXT NOT NULL)`); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for _, item := range []string{"a", "b"} {
+		if err := sdb.Exec(`INSERT INTO orders ("item name") VALUES (?)`, item); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	if err := sdb.AttachDb(testArchiveDbName, "archive"); err != nil {
+		t.Fatalf("AttachDb error: %v", err)
+	}
+	if _, err := os.Stat(testArchiveDbName); err != nil {
+		t.Errorf("Expected attached database file: %v", err)
+	}
+
+	// The destination table is created from the source definition.
+	if err := sdb.CopyTable("orders", "archive.orders"); err != nil {
+		t.Fatalf("CopyTable error: %v", err)
+	}
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM archive.orders", &count); err != nil || count != 2 {
+		t.Errorf("Expected 2 archived orders, but was %v: %v", count, err)
+	}
+	if err := sdb.Exec(`INSERT INTO archive.orders (id) VALUES (9)`); err == nil {
+		t.Error("Expected the copied table to keep its NOT NULL constraint")
+	}
+
+	// Copying into an existing table appends the rows.
+	if err := sdb.CopyTable("archive.orders", "orders_copy"); err != nil {
+		t.Fatalf("CopyTable error: %v", err)
+	}
+	if err := sdb.Exec("DELETE FROM archive.orders WHERE id = 1"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := sdb.CopyTable("archive.orders", "orders_copy"); err == nil {
+		t.Error("Expected primary key conflict copying into the existing table")
+	}
+	if err := sdb.CopyTable("archive.missing", "orders_copy2"); err == nil {
+		t.Error("Expected error copying a missing table")
+	}
+
+	if err := sdb.DetachDb("archive"); err != nil {
+		t.Errorf("DetachDb error: %v", err)
+	}
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM archive.orders", &count); err == nil {
+		t.Error("Expected error querying a detached database")
+	}
+	if err := sdb.DetachDb("archive"); err == nil {
+		t.Error("Expected error detaching a database that is not attached")
+	}
+}
+
+func TestRenameCreateTable(t *testing.T) {
+	for def, expected := range map[string]string{
+		"CREATE TABLE t (id INTEGER)":          `CREATE TABLE "x" (id INTEGER)`,
+		"CREATE TABLE t(id INTEGER)":           `CREATE TABLE "x"(id INTEGER)`,
+		`CREATE TABLE "my ""t""" (id INTEGER)`: `CREATE TABLE "x" (id INTEGER)`,
+		"CREATE TABLE [my t](id INTEGER)":      `CREATE TABLE "x"(id INTEGER)`,
+	} {
+		actual, err := renameCreateTable(def, `"x"`)
+		if err != nil || actual != expected {
+			t.Errorf("Expected %s, but was %s: %v", expected, actual, err)
+		}
+	}
+	if QuoteIdentifier(`a"b`) != `"a""b"` {
+		t.Errorf("Unexpected quoted identifier %s", QuoteIdentifier(`a"b`))
+	}
+}
+
+func TestAttachDb_ConnectionInUse(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer removeDbFiles(testArchiveDbName)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("orders (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	err := sdb.dedicated(func(tx *SQLDb) error {
+		if err := tx.BeginTrans(); err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO orders (id) VALUES (1)"); err != nil {
+			return err
+		}
+		// Attaching leaves the connection of the open transaction alone.
+		if err := sdb.AttachDb(testArchiveDbName, "archive"); err != nil {
+			t.Errorf("AttachDb error: %v", err)
+		}
+		if _, err := tx.QueryExists("SELECT name FROM archive.sqlite_master"); err == nil {
+			t.Error("Expected the connection in use not to be attached")
+		}
+		return tx.CommitTrans()
+	})
+	if err != nil {
+		t.Fatalf("Transaction error: %v", err)
+	}
+	if count, err := sdb.Count("orders", ""); err != nil || count != 1 {
+		t.Errorf("Expected the transaction to commit 1 row, but was %v: %v", count, err)
+	}
+	// The connection of the transaction is replaced by one with the database attached.
+	for i := 0; i < 3; i++ {
+		if err := sdb.CopyTable("orders", "archive.orders"+string(rune('a'+i))); err != nil {
+			t.Errorf("CopyTable error: %v", err)
+		}
+	}
+	if err := sdb.DetachDb("archive"); err != nil {
+		t.Errorf("DetachDb error: %v", err)
+	}
+	if exists, err := sdb.QueryExists("SELECT name FROM archive.sqlite_master"); err == nil {
+		t.Errorf("Expected error querying a detached database, but was %v", exists)
+	}
+}
@@ -0,0 +1,122 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// The number of pages copied by each backup step unless overridden.
+const defaultBackupStepPages = 100
+
+// How long to wait before retrying a backup step that could not make progress
+// because the source database was locked.
+const backupBusyDelay = 10 * time.Millisecond
+
+// BackupOption - Configure an online backup.
+type BackupOption func(cfg *backupConfig)
+
+type backupConfig struct {
+	stepPages int
+	stepDelay time.Duration
+	progress  func(remaining, pageCount int)
+}
+
+// WithBackupProgress - Call fn after each backup step with the number of pages remaining
+// to be copied and the total number of pages in the source database.
+func WithBackupProgress(fn func(remaining, pageCount int)) BackupOption {
+	return func(cfg *backupConfig) {
+		cfg.progress = fn
+	}
+}
+
+// WithBackupStepPages - Copy the given number of pages in each backup step. Fewer pages
+// per step holds the source read lock for less time. A negative value copies the whole
+// database in a single step.
+func WithBackupStepPages(pages int) BackupOption {
+	return func(cfg *backupConfig) {
+		cfg.stepPages = pages
+	}
+}
+
+// WithBackupStepDelay - Pause between backup steps so writers can make progress.
+func WithBackupStepDelay(delay time.Duration) BackupOption {
+	return func(cfg *backupConfig) {
+		cfg.stepDelay = delay
+	}
+}
+
+// BackupTo - Take a consistent online backup of the database into the file at destPath.
+// The database remains open and usable while the backup runs. Any existing database
+// at destPath is overwritten.
+func (sdb *SQLDb) BackupTo(destPath string, opts ...BackupOption) error {
+	dest, err := OpenDb(destPath)
+	defer dest.Close()
+	if err != nil {
+		return err
+	}
+	return sdb.BackupToDb(dest, opts...)
+}
+
+// BackupToDb - Take a consistent online backup of the database into the dest database.
+// The contents of the dest database are replaced.
+func (sdb *SQLDb) BackupToDb(dest *SQLDb, opts ...BackupOption) error {
+	cfg := backupConfig{stepPages: defaultBackupStepPages}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx := context.Background()
+	srcConn, err := sdb.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("dberror: backup source connection: %v", err)
+	}
+	defer srcConn.Close()
+	destConn, err := dest.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("dberror: backup destination connection: %v", err)
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			return runBackup(sqliteConn(destDriverConn), sqliteConn(srcDriverConn), cfg)
+		})
+	})
+}
+
+func runBackup(destConn, srcConn *sqlite3.SQLiteConn, cfg backupConfig) error {
+	backup, err := destConn.Backup("main", srcConn, "main")
+	if err != nil {
+		return fmt.Errorf("dberror: starting backup: %v", err)
+	}
+	lastRemaining := -1
+	for {
+		done, err := backup.Step(cfg.stepPages)
+		if err != nil {
+			backup.Finish()
+			return fmt.Errorf("dberror: backup step: %v", err)
+		}
+		remaining := backup.Remaining()
+		if cfg.progress != nil {
+			cfg.progress(remaining, backup.PageCount())
+		}
+		if done {
+			break
+		}
+		switch {
+		case remaining == lastRemaining:
+			// The source was locked, so no pages were copied.
+			time.Sleep(max(cfg.stepDelay, backupBusyDelay))
+		case cfg.stepDelay > 0:
+			time.Sleep(cfg.stepDelay)
+		}
+		lastRemaining = remaining
+	}
+	if err := backup.Finish(); err != nil {
+		return fmt.Errorf("dberror: finishing backup: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package sqldb
+
+import (
+	"os"
+	"testing"
+)
+
+const testBackupDbName = "TestBackupDb"
+
+func TestBackupTo(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testBackupDbName)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	rows := make([][]interface{}, 1000)
+	for i := range rows {
+		rows[i] = []interface{}{i}
+	}
+	if err := sdb.InsertMany("testtable", []string{"id"}, rows); err != nil {
+		t.Fatalf("InsertMany error: %v", err)
+	}
+
+	steps := 0
+	lastRemaining := -1
+	err := sdb.BackupTo(testBackupDbName, WithBackupStepPages(1), WithBackupProgress(func(remaining, pageCount int) {
+		steps++
+		lastRemaining = remaining
+		if pageCount == 0 {
+			t.Error("Expected backup page count")
+		}
+	}))
+	if err != nil {
+		t.Fatalf("BackupTo error: %v", err)
+	}
+	if steps < 2 {
+		t.Errorf("Expected several backup steps, but was %v", steps)
+	}
+	if lastRemaining != 0 {
+		t.Errorf("Expected no pages remaining, but was %v", lastRemaining)
+	}
+
+	// The source database is still usable after the backup.
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1000)"); err != nil {
+		t.Errorf("Insert after backup error: %v", err)
+	}
+
+	backup, err := OpenDb(testBackupDbName)
+	if err != nil {
+		t.Fatalf("OpenDb backup error: %v", err)
+	}
+	defer closeDb(t, &backup)
+	var count int
+	if err := backup.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if count != 1000 {
+		t.Errorf("Expected 1000 rows in backup, but was %v", count)
+	}
+	if gkey, err := backup.GetGkey(); err != nil || gkey != 1 {
+		t.Errorf("Expected backup gkey 1, but was %v: %v", gkey, err)
+	}
+}
+
+func TestBackupToDb(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+
+	dest, err := OpenDb(":memory:")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &dest)
+	if err := sdb.BackupToDb(dest); err != nil {
+		t.Fatalf("BackupToDb error: %v", err)
+	}
+	exists, err := dest.QueryExists("SELECT patchid FROM version")
+	if err != nil || !exists {
+		t.Errorf("Expected version table in backup: %v, %v", exists, err)
+	}
+}
@@ -0,0 +1,125 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// BatchStmt - A statement and its bound arguments to run as part of a batch.
+type BatchStmt struct {
+	SQL  string
+	Args []interface{}
+}
+
+// ItemError - The failure of a single item of a batch operation.
+type ItemError struct {
+	// Index is the position of the failed item in the batch.
+	Index int
+	Err   error
+}
+
+func (ie *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", ie.Index, ie.Err)
+}
+
+// Unwrap - Return the error of the failed item.
+func (ie *ItemError) Unwrap() error {
+	return ie.Err
+}
+
+// MultiError - The failures of the individual items of a batch operation, in item order.
+// errors.Is and errors.As match against each of the item errors.
+type MultiError struct {
+	Errors []*ItemError
+}
+
+func (me *MultiError) Error() string {
+	msgs := make([]string, len(me.Errors))
+	for i, ie := range me.Errors {
+		msgs[i] = ie.Error()
+	}
+	return fmt.Sprintf("dberror: %d batch items failed: %s", len(me.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap - Return the item errors.
+func (me *MultiError) Unwrap() []error {
+	errs := make([]error, len(me.Errors))
+	for i, ie := range me.Errors {
+		errs[i] = ie
+	}
+	return errs
+}
+
+// Indexes - Return the positions of the failed items in the batch.
+func (me *MultiError) Indexes() []int {
+	indexes := make([]int, len(me.Errors))
+	for i, ie := range me.Errors {
+		indexes[i] = ie.Index
+	}
+	return indexes
+}
+
+// add records the failure of the item at index.
+func (me *MultiError) add(index int, err error) {
+	me.Errors = append(me.Errors, &ItemError{Index: index, Err: err})
+}
+
+// errorOrNil returns the MultiError if any items failed, or nil otherwise.
+func (me *MultiError) errorOrNil() error {
+	if len(me.Errors) == 0 {
+		return nil
+	}
+	return me
+}
+
+// offsetMultiError shifts the item indexes of a MultiError by offset, for batches
+// that were run in parts.
+func offsetMultiError(err error, offset int) error {
+	var me *MultiError
+	if errors.As(err, &me) {
+		for _, ie := range me.Errors {
+			ie.Index += offset
+		}
+	}
+	return err
+}
+
+// ExecBatch - Execute the statements in order inside a single save point.
+// If any statement fails, the whole batch is rolled back and a *MultiError
+// reporting every failed statement is returned. A statement that rolls back the
+// whole transaction, such as one with OR ROLLBACK, ends the batch.
+func (sdb *SQLDb) ExecBatch(stmts []BatchStmt) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		// A failed statement does not abort the transaction, so run them all
+		// to find every failure.
+		var me MultiError
+		for i, stmt := range stmts {
+			if err := tx.Exec(stmt.SQL, stmt.Args...); err != nil {
+				me.add(i, err)
+				if tx.txnAborted() || (isConstraint(err) && rollsBackOnConflict(stmt.SQL)) {
+					// The rest of the batch would run outside of any transaction.
+					break
+				}
+			}
+		}
+		return me.errorOrNil()
+	})
+}
+
+// isConstraint reports whether the error is a constraint violation.
+func isConstraint(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint
+}
+
+// rollsBackOnConflict reports whether the statement rolls back the whole transaction when it
+// violates a constraint, as with INSERT OR ROLLBACK.
+func rollsBackOnConflict(stmt string) bool {
+	return strings.Contains(" "+strings.ToUpper(strings.Join(strings.Fields(stmt), " "))+" ", " OR ROLLBACK ")
+}
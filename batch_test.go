@@ -0,0 +1,138 @@
+package sqldb
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExecBatch(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "CREATE TABLE testtable (id INTEGER, field1 TEXT)"},
+		{SQL: "INSERT INTO testtable (id, field1) VALUES (?, ?)", Args: []interface{}{1, "a"}},
+		{SQL: "INSERT INTO testtable (id, field1) VALUES (?, ?)", Args: []interface{}{2, "b"}},
+	})
+	if err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, but was %v", count)
+	}
+}
+
+func TestExecBatch_RollbackOnError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "CREATE TABLE testtable (id INTEGER)"},
+		{SQL: "INSERT INTO testtable (id) VALUES (?)", Args: []interface{}{1}},
+		{SQL: "INSERT INTO notatable (id) VALUES (?)", Args: []interface{}{2}},
+	})
+	if err == nil {
+		t.Fatal("ExecBatch did not return an error")
+	}
+
+	exists, err := sdb.QueryExists("SELECT name FROM sqlite_master WHERE name = 'testtable'")
+	if err != nil || exists {
+		t.Errorf("Expected batch to be rolled back: %v, %v", exists, err)
+	}
+}
+
+func TestExecBatch_MultiError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "INSERT INTO testtable (id) VALUES (?)", Args: []interface{}{1}},
+		{SQL: "INSERT INTO notatable (id) VALUES (?)", Args: []interface{}{2}},
+		{SQL: "INSERT INTO testtable (id) VALUES (?)", Args: []interface{}{3}},
+		{SQL: "INSERT INTO testtable (id) VALUES (?)", Args: []interface{}{1}},
+	})
+	var me *MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("Expected MultiError, but was %v", err)
+	}
+	if !reflect.DeepEqual(me.Indexes(), []int{1, 3}) {
+		t.Errorf("Expected failed indexes [1 3], but was %v", me.Indexes())
+	}
+	var ie *ItemError
+	if !errors.As(err, &ie) || ie.Index != 1 {
+		t.Errorf("Expected first ItemError at index 1, but was %v", ie)
+	}
+}
+
+func TestMultiError_Is(t *testing.T) {
+	itemErr := errors.New("item failure")
+	var me MultiError
+	me.add(0, errors.New("other failure"))
+	me.add(4, itemErr)
+	err := offsetMultiError(me.errorOrNil(), 10)
+	if !errors.Is(err, itemErr) {
+		t.Error("Expected MultiError to match an item error")
+	}
+	if !reflect.DeepEqual(me.Indexes(), []int{10, 14}) {
+		t.Errorf("Expected offset indexes [10 14], but was %v", me.Indexes())
+	}
+	var none MultiError
+	if none.errorOrNil() != nil {
+		t.Error("Expected no error for an empty MultiError")
+	}
+}
+
+func TestExecBatch_TransactionRolledBack(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "INSERT INTO testtable (id) VALUES (2)"},
+		{SQL: "INSERT OR ROLLBACK INTO testtable (id) VALUES (1)"},
+		{SQL: "INSERT INTO testtable (id) VALUES (3)"},
+	})
+	var me *MultiError
+	if !errors.As(err, &me) || !reflect.DeepEqual(me.Indexes(), []int{1}) {
+		t.Errorf("Expected MultiError for the rolled back statement, but was %v", err)
+	}
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 1 {
+		t.Errorf("Expected only the row from before the batch, but was %v: %v", count, err)
+	}
+	if logged.Len() > 0 {
+		t.Errorf("Expected nothing to be logged, but was %q", logged.String())
+	}
+}
@@ -0,0 +1,118 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The number of bytes of a blob stored in each chunk row.
+const blobChunkSize = 256 * 1024
+
+// The internal table that holds the chunks of the blobs written by WriteBlob.
+const blobChunkTableName = "_sqldb_blob_chunks"
+
+// WriteBlob - Replace the blob in the column of the table row with the rowid with the contents
+// of the reader, and return the number of bytes written. The go-sqlite3 driver has no incremental
+// blob I/O, so the blob is stored as one row per chunk in an internal table, and the column is set
+// to an empty blob. Only one chunk is held in memory at a time. Read the blob with ReadBlob. The
+// chunks are deleted when the column is updated or the row is deleted. The row must exist. The
+// write runs inside a save point, so the blob is left unchanged if reading or writing fails.
+func (sdb *SQLDb) WriteBlob(table, column string, rowid int64, r io.Reader) (int64, error) {
+	var written int64
+	err := sdb.inSavePoint(func(tx *SQLDb) error {
+		if err := tx.createBlobChunks(table, column); err != nil {
+			return err
+		}
+		// The update trigger deletes the chunks of the previous blob.
+		res, err := tx.ExecResults(fmt.Sprintf("UPDATE %s SET %s = X'' WHERE rowid = ?", table, column), rowid)
+		if err != nil {
+			return err
+		}
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return fmt.Errorf("dberror: no row %d in %s to write blob to: %w", rowid, table, ErrNoRows)
+		}
+		insertChunk := fmt.Sprintf("INSERT INTO %s (tbl, col, row, seq, data) VALUES (?, ?, ?, ?, ?)", blobChunkTableName)
+		buf := make([]byte, blobChunkSize)
+		for seq := 0; ; seq++ {
+			n, err := io.ReadFull(r, buf)
+			if n > 0 {
+				if err := tx.Exec(insertChunk, strings.ToLower(table), strings.ToLower(column), rowid, seq, buf[:n]); err != nil {
+					return err
+				}
+				written += int64(n)
+			}
+			switch err {
+			case nil:
+			case io.EOF, io.ErrUnexpectedEOF:
+				return nil
+			default:
+				return fmt.Errorf("dberror: reading blob for %s row %d: %v", table, rowid, err)
+			}
+		}
+	})
+	return written, err
+}
+
+// createBlobChunks creates the chunk table, and the triggers that delete the chunks of the column
+// when it is updated, or when its row is deleted, unless they exist.
+func (sdb *SQLDb) createBlobChunks(table, column string) error {
+	err := sdb.CreateTable(fmt.Sprintf(`IF NOT EXISTS %s (tbl TEXT NOT NULL, col TEXT NOT NULL,
+		row INTEGER NOT NULL, seq INTEGER NOT NULL, data BLOB NOT NULL, PRIMARY KEY (tbl, col, row, seq))`, blobChunkTableName))
+	if err != nil {
+		return err
+	}
+	tbl, col := strings.ToLower(table), strings.ToLower(column)
+	deleted := fmt.Sprintf("%s AFTER DELETE ON %s BEGIN DELETE FROM %s WHERE tbl = %s AND row = old.rowid; END",
+		QuoteIdentifier(blobChunkTableName+"_"+tbl+"_ad"), table, blobChunkTableName, quoteKey(tbl))
+	if err := sdb.CreateTriggerIfNotExists(deleted); err != nil {
+		return err
+	}
+	updated := fmt.Sprintf("%s AFTER UPDATE OF %s ON %s BEGIN DELETE FROM %s WHERE tbl = %s AND col = %s AND row = old.rowid; END",
+		QuoteIdentifier(blobChunkTableName+"_"+tbl+"_"+col+"_au"), column, table, blobChunkTableName, quoteKey(tbl), quoteKey(col))
+	return sdb.CreateTriggerIfNotExists(updated)
+}
+
+// ReadBlob - Copy the blob in the column of the table row with the rowid to the writer,
+// and return the number of bytes copied. A blob written by WriteBlob is read one chunk at
+// a time, so it is never held in memory as a whole. A value stored in the column by other
+// means is read whole. A NULL column copies no bytes.
+// Returns an error wrapping ErrNoRows if the row does not exist.
+func (sdb *SQLDb) ReadBlob(table, column string, rowid int64, w io.Writer) (int64, error) {
+	var data []byte
+	err := sdb.SingleQueryArgs(fmt.Sprintf("SELECT CAST(%s AS BLOB) FROM %s WHERE rowid = ?", column, table),
+		[]interface{}{rowid}, &data)
+	if err != nil {
+		return 0, err
+	}
+	var copied int64
+	write := func(chunk []byte) error {
+		n, err := w.Write(chunk)
+		copied += int64(n)
+		if err != nil {
+			return fmt.Errorf("dberror: writing blob from %s row %d: %v", table, rowid, err)
+		}
+		return nil
+	}
+	if len(data) > 0 {
+		return copied, write(data)
+	}
+	chunked, err := sdb.TableExists(blobChunkTableName)
+	if err != nil || !chunked {
+		return 0, err
+	}
+	err = sdb.MultiQueryArgs(fmt.Sprintf("SELECT data FROM %s WHERE tbl = ? AND col = ? AND row = ? ORDER BY seq", blobChunkTableName),
+		[]interface{}{strings.ToLower(table), strings.ToLower(column), rowid}, func(rows *sql.Rows) (bool, error) {
+			var chunk []byte
+			if err := rows.Scan(&chunk); err != nil {
+				return false, err
+			}
+			return false, write(chunk)
+		})
+	return copied, err
+}
@@ -0,0 +1,109 @@
+package sqldb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteReadBlob(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, data BLOB)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	// Span several chunks, including zero bytes.
+	data := make([]byte, 2*blobChunkSize+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if n, err := sdb.WriteBlob("testtable", "data", 1, bytes.NewReader(data)); err != nil || n != int64(len(data)) {
+		t.Fatalf("WriteBlob wrote %v: %v", n, err)
+	}
+	var out bytes.Buffer
+	if n, err := sdb.ReadBlob("testtable", "data", 1, &out); err != nil || n != int64(len(data)) {
+		t.Fatalf("ReadBlob read %v: %v", n, err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Error("Expected the blob read to match the blob written")
+	}
+	var blobType string
+	if err := sdb.SingleQuery("SELECT typeof(data) FROM testtable WHERE id = 1", &blobType); err != nil || blobType != "blob" {
+		t.Errorf("Expected a blob column, but was %v: %v", blobType, err)
+	}
+	var chunks int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM "+blobChunkTableName, &chunks); err != nil || chunks != 3 {
+		t.Errorf("Expected the blob to be stored in 3 chunk rows, but was %v: %v", chunks, err)
+	}
+
+	if n, err := sdb.WriteBlob("testtable", "data", 1, bytes.NewReader(nil)); err != nil || n != 0 {
+		t.Errorf("WriteBlob of an empty blob wrote %v: %v", n, err)
+	}
+	out.Reset()
+	if n, err := sdb.ReadBlob("testtable", "data", 1, &out); err != nil || n != 0 {
+		t.Errorf("ReadBlob of an empty blob read %v: %v", n, err)
+	}
+
+	if _, err := sdb.WriteBlob("testtable", "data", 2, bytes.NewReader(data)); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows writing a missing row, but was %v", err)
+	}
+	if _, err := sdb.ReadBlob("testtable", "data", 2, &out); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows reading a missing row, but was %v", err)
+	}
+}
+
+func TestWriteBlob_ChunksDeleted(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, data BLOB)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1), (2)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	data := bytes.Repeat([]byte{1}, blobChunkSize+1)
+	for _, id := range []int64{1, 2} {
+		if _, err := sdb.WriteBlob("testtable", "data", id, bytes.NewReader(data)); err != nil {
+			t.Fatalf("WriteBlob error: %v", err)
+		}
+	}
+	countChunks := func() int {
+		var chunks int
+		if err := sdb.SingleQuery("SELECT COUNT(*) FROM "+blobChunkTableName, &chunks); err != nil {
+			t.Fatalf("SingleQuery error: %v", err)
+		}
+		return chunks
+	}
+	if chunks := countChunks(); chunks != 4 {
+		t.Fatalf("Expected 4 chunk rows, but was %v", chunks)
+	}
+
+	// Storing a value in the column replaces the streamed blob.
+	if err := sdb.Exec("UPDATE testtable SET data = X'0102' WHERE id = 1"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if chunks := countChunks(); chunks != 2 {
+		t.Errorf("Expected the update to delete the chunks of row 1, but there were %v", chunks)
+	}
+	var out bytes.Buffer
+	if n, err := sdb.ReadBlob("testtable", "data", 1, &out); err != nil || !bytes.Equal(out.Bytes(), []byte{1, 2}) {
+		t.Errorf("ReadBlob read %v %v: %v", n, out.Bytes(), err)
+	}
+
+	if err := sdb.Exec("DELETE FROM testtable WHERE id = 2"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if chunks := countChunks(); chunks != 0 {
+		t.Errorf("Expected deleting the row to delete its chunks, but there were %v", chunks)
+	}
+}
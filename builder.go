@@ -0,0 +1,128 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SelectBuilder - Builds a parameterized SELECT statement, and runs it on the database.
+// Start one with SQLDb.Select.
+type SelectBuilder struct {
+	sdb     *SQLDb
+	columns []string
+	from    string
+	where   []string
+	args    []interface{}
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Select - Start building a query of the columns. With no columns, all columns are selected.
+//
+//	err := sdb.Select("id", "name").From("users").Where("age > ?", 21).OrderBy("name").Limit(10).Structs(&users)
+func (sdb *SQLDb) Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{sdb: sdb, columns: columns, limit: -1}
+}
+
+// From - Set the table, or the joined tables, to select from.
+func (sb *SelectBuilder) From(table string) *SelectBuilder {
+	sb.from = table
+	return sb
+}
+
+// Where - Add a condition with its bound arguments. Multiple conditions must all be true.
+func (sb *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	sb.where = append(sb.where, cond)
+	sb.args = append(sb.args, args...)
+	return sb
+}
+
+// NotDeleted - Exclude the soft-deleted rows of the table selected from, if its conventions
+// have a soft-delete column. Call it after From.
+func (sb *SelectBuilder) NotDeleted() *SelectBuilder {
+	if cond := NotDeleted(sb.from, ""); cond != "" {
+		sb.where = append(sb.where, cond)
+	}
+	return sb
+}
+
+// OrderBy - Add columns to order the rows by, such as "name" or "created DESC".
+func (sb *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	sb.orderBy = append(sb.orderBy, columns...)
+	return sb
+}
+
+// Limit - Return at most n rows.
+func (sb *SelectBuilder) Limit(n int) *SelectBuilder {
+	sb.limit = n
+	return sb
+}
+
+// Offset - Skip the first n rows.
+func (sb *SelectBuilder) Offset(n int) *SelectBuilder {
+	sb.offset = n
+	return sb
+}
+
+// SQL - Return the statement and its bound arguments.
+func (sb *SelectBuilder) SQL() (string, []interface{}) {
+	var stmt strings.Builder
+	stmt.WriteString("SELECT ")
+	if len(sb.columns) == 0 {
+		stmt.WriteString("*")
+	} else {
+		stmt.WriteString(strings.Join(sb.columns, ", "))
+	}
+	if sb.from != "" {
+		fmt.Fprintf(&stmt, " FROM %s", sb.from)
+	}
+	args := append([]interface{}{}, sb.args...)
+	switch len(sb.where) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&stmt, " WHERE %s", sb.where[0])
+	default:
+		fmt.Fprintf(&stmt, " WHERE (%s)", strings.Join(sb.where, ") AND ("))
+	}
+	if len(sb.orderBy) > 0 {
+		fmt.Fprintf(&stmt, " ORDER BY %s", strings.Join(sb.orderBy, ", "))
+	}
+	// SQLite only allows an offset after a limit, where a negative limit means no limit.
+	if sb.limit >= 0 || sb.offset > 0 {
+		stmt.WriteString(" LIMIT ?")
+		args = append(args, sb.limit)
+	}
+	if sb.offset > 0 {
+		stmt.WriteString(" OFFSET ?")
+		args = append(args, sb.offset)
+	}
+	return stmt.String(), args
+}
+
+// Single - Run the query, and scan the first returned row into dest, as with SingleQuery.
+func (sb *SelectBuilder) Single(dest ...interface{}) error {
+	stmt, args := sb.SQL()
+	return sb.sdb.SingleQueryArgs(stmt, args, dest...)
+}
+
+// Multi - Run the query, and execute a function on the returned rows, as with MultiQueryArgs.
+func (sb *SelectBuilder) Multi(action func(rows *sql.Rows) (stop bool, err error)) error {
+	stmt, args := sb.SQL()
+	return sb.sdb.MultiQueryArgs(stmt, args, action)
+}
+
+// Structs - Run the query, and append a struct for each returned row to the slice pointed
+// to by dest, as with QueryStructs.
+func (sb *SelectBuilder) Structs(dest interface{}) error {
+	stmt, args := sb.SQL()
+	return sb.sdb.QueryStructs(dest, stmt, args...)
+}
+
+// Struct - Run the query, and scan the first returned row into the struct pointed to by
+// dest, as with QueryStruct.
+func (sb *SelectBuilder) Struct(dest interface{}) error {
+	stmt, args := sb.SQL()
+	return sb.sdb.QueryStruct(dest, stmt, args...)
+}
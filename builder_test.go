@@ -0,0 +1,71 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestSelectBuilder_SQL(t *testing.T) {
+	sdb := &SQLDb{}
+	for _, test := range []struct {
+		sb       *SelectBuilder
+		expected string
+		args     int
+	}{
+		{sdb.Select().From("t"), "SELECT * FROM t", 0},
+		{sdb.Select("a", "b").From("t").Where("a = ?", 1), "SELECT a, b FROM t WHERE a = ?", 1},
+		{sdb.Select("a").From("t").Where("a = ?", 1).Where("b IN (?, ?)", 2, 3).OrderBy("a", "b DESC").Limit(10),
+			"SELECT a FROM t WHERE (a = ?) AND (b IN (?, ?)) ORDER BY a, b DESC LIMIT ?", 4},
+		{sdb.Select("a").From("t").Offset(5), "SELECT a FROM t LIMIT ? OFFSET ?", 2},
+	} {
+		stmt, args := test.sb.SQL()
+		if stmt != test.expected || len(args) != test.args {
+			t.Errorf("Expected %s with %v args, but was %s with %v", test.expected, test.args, stmt, args)
+		}
+	}
+}
+
+func TestSelectBuilder(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for id, name := range []string{"a", "b", "c", "d"} {
+		if err := sdb.Exec("INSERT INTO testtable (id, name) VALUES (?, ?)", id+1, name); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	var name string
+	if err := sdb.Select("name").From("testtable").Where("id = ?", 2).Single(&name); err != nil || name != "b" {
+		t.Errorf("Expected b, but was %s: %v", name, err)
+	}
+	if err := sdb.Select("name").From("testtable").Where("id = ?", 9).Single(&name); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+
+	var rows []testPageRow
+	err := sdb.Select("id", "name").From("testtable").Where("id > ?", 1).OrderBy("id DESC").Limit(2).Offset(1).Structs(&rows)
+	if err != nil || len(rows) != 2 || rows[0].Name != "c" || rows[1].Name != "b" {
+		t.Errorf("Expected c and b, but was %v: %v", rows, err)
+	}
+
+	var row testPageRow
+	if err := sdb.Select("id", "name").From("testtable").OrderBy("id").Struct(&row); err != nil || row.Name != "a" {
+		t.Errorf("Expected a, but was %v: %v", row, err)
+	}
+
+	count := 0
+	err = sdb.Select("id").From("testtable").Multi(func(rows *sql.Rows) (bool, error) {
+		count++
+		return false, nil
+	})
+	if err != nil || count != 4 {
+		t.Errorf("Expected 4 rows, but was %v: %v", count, err)
+	}
+}
@@ -0,0 +1,247 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Op - The kind of change made to a row.
+type Op int
+
+const (
+	// OpInsert is a row inserted.
+	OpInsert Op = sqlite3.SQLITE_INSERT
+	// OpUpdate is a row updated.
+	OpUpdate Op = sqlite3.SQLITE_UPDATE
+	// OpDelete is a row deleted.
+	OpDelete Op = sqlite3.SQLITE_DELETE
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpInsert:
+		return "insert"
+	case OpUpdate:
+		return "update"
+	case OpDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// ChangeFunc - Called for each row changed in a committed transaction.
+type ChangeFunc func(op Op, table string, rowid int64)
+
+// OnChange - Call fn for each row inserted, updated, or deleted through this database handle.
+// Changes are reported once the statement that commits their transaction has returned
+// successfully, in the order they were made. Changes are discarded if their transaction rolls
+// back, or if a save point they were made in is rolled back. The function is called on the
+// goroutine that committed, before the commit returns to its caller, so it must return quickly
+// and must not use the database; hand the change off to another goroutine for anything more.
+// Rows changed by other processes, and rows of WITHOUT ROWID tables, are not reported.
+func (sdb *SQLDb) OnChange(fn ChangeFunc) error {
+	if fn == nil {
+		return nil
+	}
+	if !sdb.changes.add(fn) {
+		return nil
+	}
+	// Install the hooks on the first function.
+	return sdb.conns.addSetup("changes", func(conn *sqlite3.SQLiteConn) error {
+		conn.RegisterUpdateHook(func(op int, _ string, table string, rowid int64) {
+			sdb.changes.record(conn, change{op: Op(op), table: table, rowid: rowid})
+		})
+		conn.RegisterRollbackHook(func() {
+			sdb.changes.rollback(conn)
+		})
+		return nil
+	})
+}
+
+type change struct {
+	op    Op
+	table string
+	rowid int64
+}
+
+// changeFrame holds the changes made since a save point was created, or since the
+// transaction began for the frame at the bottom of the stack.
+type changeFrame struct {
+	savePoint string
+	changes   []change
+}
+
+// connChanges holds the changes of a connection's open transaction.
+type connChanges struct {
+	// frames has a frame for the transaction, and another for each save point open in it.
+	frames []changeFrame
+	// committing holds the changes of the transaction being committed by the running statement.
+	committing []change
+}
+
+// changeNotifier holds the changes made in each connection's open transaction until the
+// statement that commits it returns.
+type changeNotifier struct {
+	mu    sync.Mutex
+	funcs []ChangeFunc
+	conns map[*sqlite3.SQLiteConn]*connChanges
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{conns: make(map[*sqlite3.SQLiteConn]*connChanges)}
+}
+
+// add adds the function, and reports whether it is the first.
+func (cn *changeNotifier) add(fn ChangeFunc) bool {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	cn.funcs = append(cn.funcs, fn)
+	return len(cn.funcs) == 1
+}
+
+// record adds the change to the innermost open save point of the connection.
+func (cn *changeNotifier) record(conn *sqlite3.SQLiteConn, c change) {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	cc := cn.conns[conn]
+	if cc == nil {
+		cc = &connChanges{}
+		cn.conns[conn] = cc
+	}
+	if len(cc.frames) == 0 {
+		cc.frames = []changeFrame{{}}
+	}
+	top := &cc.frames[len(cc.frames)-1]
+	top.changes = append(top.changes, c)
+}
+
+// commit is called by the commit hook, before the commit is durable, and sets the changes
+// of the connection's transaction aside until the committing statement returns.
+func (cn *changeNotifier) commit(conn *sqlite3.SQLiteConn) {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	cc := cn.conns[conn]
+	if cc == nil {
+		return
+	}
+	for _, frame := range cc.frames {
+		cc.committing = append(cc.committing, frame.changes...)
+	}
+	cc.frames = nil
+}
+
+// rollback discards the changes of the connection's transaction.
+func (cn *changeNotifier) rollback(conn *sqlite3.SQLiteConn) {
+	cn.mu.Lock()
+	delete(cn.conns, conn)
+	cn.mu.Unlock()
+}
+
+// executed is called after each statement run on the connection has returned, with its error.
+// It keeps track of the save points the statement created, released, or rolled back, and
+// reports the changes of a transaction that the statement committed.
+func (cn *changeNotifier) executed(conn *sqlite3.SQLiteConn, stmt string, err error) {
+	cn.mu.Lock()
+	cc := cn.conns[conn]
+	if len(cn.funcs) == 0 || (cc == nil && !isSavePointStmt(stmt)) {
+		cn.mu.Unlock()
+		return
+	}
+	if cc == nil {
+		cc = &connChanges{}
+		cn.conns[conn] = cc
+	}
+	if err == nil {
+		cc.savePoint(stmt)
+	}
+	var committed []change
+	if len(cc.committing) > 0 {
+		switch {
+		case err == nil:
+			committed = cc.committing
+		case !conn.AutoCommit():
+			// The commit failed, such as when the database is busy, but the transaction is still open.
+			cc.frames = append([]changeFrame{{changes: cc.committing}}, cc.frames...)
+		}
+		cc.committing = nil
+	}
+	funcs := cn.funcs
+	cn.mu.Unlock()
+
+	for _, c := range committed {
+		for _, fn := range funcs {
+			fn(c.op, c.table, c.rowid)
+		}
+	}
+}
+
+// closed forgets the changes of a connection that has been closed.
+func (cn *changeNotifier) closed(conn *sqlite3.SQLiteConn) {
+	cn.rollback(conn)
+}
+
+// savePoint updates the frames for a SAVEPOINT, RELEASE, or ROLLBACK TO statement.
+// ROLLBACK TO does not call the rollback hook, so the changes it undoes are dropped here.
+func (cc *connChanges) savePoint(stmt string) {
+	op, name := parseSavePointStmt(stmt)
+	switch op {
+	case "SAVEPOINT":
+		if len(cc.frames) == 0 {
+			cc.frames = []changeFrame{{}}
+		}
+		cc.frames = append(cc.frames, changeFrame{savePoint: name})
+	case "RELEASE":
+		// Releasing the outermost save point of a transaction it began commits it, and the
+		// commit hook has emptied the frames already.
+		if i := cc.findSavePoint(name); i > 0 {
+			for _, frame := range cc.frames[i:] {
+				cc.frames[i-1].changes = append(cc.frames[i-1].changes, frame.changes...)
+			}
+			cc.frames = cc.frames[:i]
+		}
+	case "ROLLBACK TO":
+		// The save point remains open after it is rolled back to.
+		if i := cc.findSavePoint(name); i > 0 {
+			cc.frames = cc.frames[:i+1]
+			cc.frames[i].changes = nil
+		}
+	}
+}
+
+// findSavePoint returns the index of the innermost frame of the named save point, or -1.
+func (cc *connChanges) findSavePoint(name string) int {
+	for i := len(cc.frames) - 1; i > 0; i-- {
+		if strings.EqualFold(cc.frames[i].savePoint, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSavePointStmt(stmt string) bool {
+	op, _ := parseSavePointStmt(stmt)
+	return op != ""
+}
+
+// parseSavePointStmt returns SAVEPOINT, RELEASE, or ROLLBACK TO and the save point name for
+// a statement that creates, releases, or rolls back to a save point, or else empty strings.
+func parseSavePointStmt(stmt string) (op string, name string) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+	if len(fields) < 2 {
+		return "", ""
+	}
+	name = strings.Trim(fields[len(fields)-1], "\"`[]'")
+	keywords := strings.ToUpper(strings.Join(fields[:len(fields)-1], " "))
+	switch keywords {
+	case "SAVEPOINT":
+		return "SAVEPOINT", name
+	case "RELEASE", "RELEASE SAVEPOINT":
+		return "RELEASE", name
+	case "ROLLBACK TO", "ROLLBACK TO SAVEPOINT", "ROLLBACK TRANSACTION TO", "ROLLBACK TRANSACTION TO SAVEPOINT":
+		return "ROLLBACK TO", name
+	}
+	return "", ""
+}
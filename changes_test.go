@@ -0,0 +1,171 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOnChange(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var changes []string
+	if err := sdb.OnChange(func(op Op, table string, rowid int64) {
+		mu.Lock()
+		changes = append(changes, fmt.Sprintf("%v %s %d", op, table, rowid))
+		mu.Unlock()
+	}); err != nil {
+		t.Fatalf("OnChange error: %v", err)
+	}
+	takeChanges := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		s := strings.Join(changes, ", ")
+		changes = nil
+		return s
+	}
+
+	if err := sdb.Exec("INSERT INTO testtable (id, name) VALUES (1, 'a')"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if s := takeChanges(); s != "insert testtable 1" {
+		t.Errorf("Expected insert, but was %s", s)
+	}
+
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	sdb.Exec("INSERT INTO testtable (id, name) VALUES (2, 'b')")
+	sdb.Exec("UPDATE testtable SET name = 'c' WHERE id = 1")
+	if s := takeChanges(); s != "" {
+		t.Errorf("Expected no changes before commit, but was %s", s)
+	}
+	if err := sdb.CommitTrans(); err != nil {
+		t.Fatalf("CommitTrans error: %v", err)
+	}
+	if s := takeChanges(); s != "insert testtable 2, update testtable 1" {
+		t.Errorf("Expected insert and update, but was %s", s)
+	}
+
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	sdb.Exec("DELETE FROM testtable WHERE id = 2")
+	if err := sdb.RollbackTrans(); err != nil {
+		t.Fatalf("RollbackTrans error: %v", err)
+	}
+	if s := takeChanges(); s != "" {
+		t.Errorf("Expected rolled back changes to be discarded, but was %s", s)
+	}
+
+	if err := sdb.Exec("DELETE FROM testtable WHERE id = 2"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if s := takeChanges(); s != "delete testtable 2" {
+		t.Errorf("Expected delete, but was %s", s)
+	}
+}
+
+func TestOnChange_RolledBackSavePoint(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	var mu sync.Mutex
+	var rowids []int64
+	if err := sdb.OnChange(func(op Op, table string, rowid int64) {
+		mu.Lock()
+		rowids = append(rowids, rowid)
+		mu.Unlock()
+	}); err != nil {
+		t.Fatalf("OnChange error: %v", err)
+	}
+	takeRowids := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		s := fmt.Sprint(rowids)
+		rowids = nil
+		return s
+	}
+
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "INSERT INTO testtable (id) VALUES (5)"},
+		{SQL: "INSERT INTO notatable (id) VALUES (6)"},
+	})
+	if err == nil {
+		t.Fatal("ExecBatch did not return an error")
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (7)"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if s := takeRowids(); s != "[7]" {
+		t.Errorf("Expected only the committed row [7], but was %s", s)
+	}
+
+	// Only the changes of the rolled back save point are discarded.
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	sdb.Exec("INSERT INTO testtable (id) VALUES (1)")
+	errRollback := errors.New("rollback")
+	err = sdb.NestedTxn(func() error {
+		sdb.Exec("INSERT INTO testtable (id) VALUES (2)")
+		return sdb.NestedTxn(func() error {
+			sdb.Exec("INSERT INTO testtable (id) VALUES (3)")
+			return errRollback
+		})
+	})
+	if !errors.Is(err, errRollback) {
+		t.Errorf("Expected the rollback error, but was %v", err)
+	}
+	err = sdb.NestedTxn(func() error {
+		return sdb.Exec("INSERT INTO testtable (id) VALUES (4)")
+	})
+	if err != nil {
+		t.Errorf("NestedTxn error: %v", err)
+	}
+	if s := takeRowids(); s != "[]" {
+		t.Errorf("Expected no changes before commit, but was %s", s)
+	}
+	if err := sdb.CommitTrans(); err != nil {
+		t.Fatalf("CommitTrans error: %v", err)
+	}
+	if s := takeRowids(); s != "[1 4]" {
+		t.Errorf("Expected the committed rows [1 4], but was %s", s)
+	}
+}
+
+func TestParseSavePointStmt(t *testing.T) {
+	tests := []struct {
+		stmt, op, name string
+	}{
+		{"SAVEPOINT sp_1", "SAVEPOINT", "sp_1"},
+		{"release savepoint sp_1;", "RELEASE", "sp_1"},
+		{"RELEASE sp_1", "RELEASE", "sp_1"},
+		{"ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK TO", "sp_1"},
+		{"ROLLBACK TRANSACTION TO sp_1", "ROLLBACK TO", "sp_1"},
+		{"ROLLBACK", "", ""},
+		{"INSERT INTO t VALUES (1)", "", ""},
+	}
+	for _, tt := range tests {
+		op, name := parseSavePointStmt(tt.stmt)
+		if op != tt.op || (op != "" && name != tt.name) {
+			t.Errorf("%s: expected %q %q, but was %q %q", tt.stmt, tt.op, tt.name, op, name)
+		}
+	}
+}
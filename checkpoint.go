@@ -0,0 +1,146 @@
+package sqldb
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckpointMode - How a WAL checkpoint copies the WAL back into the database file.
+// See the SQLite documentation of sqlite3_wal_checkpoint_v2.
+type CheckpointMode string
+
+const (
+	// CheckpointPassive copies as many frames as possible without waiting for readers or writers.
+	CheckpointPassive CheckpointMode = "PASSIVE"
+	// CheckpointFull waits for writers, then copies every frame, waiting for readers as needed.
+	CheckpointFull CheckpointMode = "FULL"
+	// CheckpointRestart is like CheckpointFull, and then waits for readers to finish with the
+	// WAL, so that the next writer starts it over from the beginning.
+	CheckpointRestart CheckpointMode = "RESTART"
+	// CheckpointTruncate is like CheckpointRestart, and then truncates the -wal file to zero bytes.
+	CheckpointTruncate CheckpointMode = "TRUNCATE"
+)
+
+// CheckpointResult - The outcome of a WAL checkpoint.
+type CheckpointResult struct {
+	// Busy reports that the checkpoint could not finish, because of other readers or writers.
+	Busy bool
+	// LogFrames is the number of frames in the WAL, or -1 if the database is not in WAL mode.
+	LogFrames int
+	// CheckpointedFrames is the number of frames copied into the database file,
+	// or -1 if the database is not in WAL mode.
+	CheckpointedFrames int
+}
+
+// WalCheckpoint - Checkpoint the WAL in the mode, copying committed transactions from the
+// -wal file back into the database file.
+func (sdb *SQLDb) WalCheckpoint(mode CheckpointMode) (CheckpointResult, error) {
+	var res CheckpointResult
+	switch mode {
+	case CheckpointPassive, CheckpointFull, CheckpointRestart, CheckpointTruncate:
+	default:
+		return res, fmt.Errorf("dberror: unknown checkpoint mode: %s", mode)
+	}
+	if err := sdb.checkWritable("PRAGMA wal_checkpoint"); err != nil {
+		return res, err
+	}
+	err := sdb.SingleQuery(fmt.Sprintf("PRAGMA wal_checkpoint(%s)", mode), &res.Busy, &res.LogFrames, &res.CheckpointedFrames)
+	if err != nil {
+		return res, err
+	}
+	// The checkpoint did not complete if it was blocked, or if the database is not in WAL mode.
+	if !res.Busy && res.LogFrames >= 0 {
+		sdb.wal.checkpointed()
+	}
+	return res, nil
+}
+
+// WalSize - Return the size in bytes of the -wal file, which is zero when the database is
+// not in WAL mode. Returns ErrNotFileDb for in-memory databases.
+func (sdb *SQLDb) WalSize() (int64, error) {
+	path := walFilePath(sdb.filename)
+	if path == "" {
+		return 0, ErrNotFileDb
+	}
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// AutoCheckpoint - When a background goroutine checkpoints the WAL.
+type AutoCheckpoint struct {
+	// Interval is how often the size of the -wal file is checked.
+	Interval time.Duration
+	// MaxBytes is the size of the -wal file beyond which it is checkpointed.
+	MaxBytes int64
+	// Mode is the checkpoint mode, or CheckpointTruncate if empty, which shrinks the -wal file.
+	Mode CheckpointMode
+	// OnError is called with any error of a checkpoint, if not nil.
+	OnError func(err error)
+}
+
+// WithAutoCheckpoint - Checkpoint the WAL in the background whenever the -wal file grows beyond
+// the configured size, in addition to SQLite's own automatic checkpoints, which never shrink
+// the file. The goroutine stops when the database is closed. Ignored for read-only databases,
+// and when the interval is not positive.
+func WithAutoCheckpoint(cfg AutoCheckpoint) Option {
+	return func(sdb *SQLDb) {
+		if cfg.Interval <= 0 {
+			sdb.autoCheckpoint = nil
+			return
+		}
+		sdb.autoCheckpoint = &autoCheckpointer{cfg: cfg}
+	}
+}
+
+// autoCheckpointer runs the background checkpoints of a database.
+type autoCheckpointer struct {
+	cfg  AutoCheckpoint
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (ac *autoCheckpointer) start(sdb *SQLDb) {
+	if ac.cfg.Mode == "" {
+		ac.cfg.Mode = CheckpointTruncate
+	}
+	ac.stop = make(chan struct{})
+	ac.done = make(chan struct{})
+	go ac.run(sdb)
+}
+
+func (ac *autoCheckpointer) run(sdb *SQLDb) {
+	defer close(ac.done)
+	ticker := time.NewTicker(ac.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ac.stop:
+			return
+		case <-ticker.C:
+		}
+		size, err := sdb.WalSize()
+		if err == nil && size > ac.cfg.MaxBytes {
+			_, err = sdb.WalCheckpoint(ac.cfg.Mode)
+		}
+		if err != nil && ac.cfg.OnError != nil {
+			ac.cfg.OnError(err)
+		}
+	}
+}
+
+// close stops the goroutine, and waits for any checkpoint it is running to finish.
+func (ac *autoCheckpointer) close() {
+	ac.once.Do(func() {
+		close(ac.stop)
+		<-ac.done
+	})
+}
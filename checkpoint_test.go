@@ -0,0 +1,84 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWalCheckpoint(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testWalDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if size, err := sdb.WalSize(); err != nil || size == 0 {
+		t.Errorf("Expected the WAL to have grown, but was %v: %v", size, err)
+	}
+
+	res, err := sdb.WalCheckpoint(CheckpointPassive)
+	if err != nil {
+		t.Fatalf("WalCheckpoint error: %v", err)
+	}
+	if res.Busy || res.LogFrames == 0 || res.CheckpointedFrames != res.LogFrames {
+		t.Errorf("Expected every frame checkpointed, but was %+v", res)
+	}
+	if _, err := sdb.WalCheckpoint(CheckpointTruncate); err != nil {
+		t.Fatalf("WalCheckpoint error: %v", err)
+	}
+	if size, err := sdb.WalSize(); err != nil || size != 0 {
+		t.Errorf("Expected the WAL to be truncated, but was %v: %v", size, err)
+	}
+	if stats := sdb.WalStats(); stats.Checkpoints != 2 {
+		t.Errorf("Expected 2 checkpoints, but was %v", stats.Checkpoints)
+	}
+	if _, err := sdb.WalCheckpoint("SOMETIMES"); err == nil {
+		t.Error("WalCheckpoint with an unknown mode did not return an error")
+	}
+}
+
+func TestWalSizeMemoryDb(t *testing.T) {
+	sdb, err := OpenDb(":memory:")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if _, err := sdb.WalSize(); !errors.Is(err, ErrNotFileDb) {
+		t.Errorf("Expected ErrNotFileDb, but was %v", err)
+	}
+}
+
+func TestAutoCheckpoint(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testWalDbName, WithAutoCheckpoint(AutoCheckpoint{
+		Interval: 5 * time.Millisecond,
+		MaxBytes: 1,
+		OnError:  func(err error) { t.Errorf("Auto checkpoint error: %v", err) },
+	}))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sdb.WalStats().Checkpoints == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if size, err := sdb.WalSize(); err != nil || size != 0 {
+		t.Errorf("Expected the WAL to be truncated, but was %v: %v", size, err)
+	}
+}
@@ -0,0 +1,244 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"sync"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// connector opens sqlite3 connections with the SQLDb connect hook installed.
+type connector struct {
+	dsn     string
+	driver  *sqlite3.SQLiteDriver
+	conns   *connections
+	changes *changeNotifier
+}
+
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedConn{SQLiteConn: conn.(*sqlite3.SQLiteConn), conns: c.conns, changes: c.changes}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// trackedConn is a sqlite3 connection that is forgotten by the live connections when closed.
+// It tells the change notifier when each statement run on it has returned.
+type trackedConn struct {
+	*sqlite3.SQLiteConn
+	conns   *connections
+	changes *changeNotifier
+}
+
+// IsValid reports whether the connection can be returned to the pool. A connection opened
+// before a pool setup changed is closed instead.
+func (tc *trackedConn) IsValid() bool {
+	return tc.conns.current(tc.SQLiteConn)
+}
+
+// ResetSession refuses the reuse of a connection opened before a pool setup changed, so the
+// pool opens a new connection with the current setup in its place.
+func (tc *trackedConn) ResetSession(_ context.Context) error {
+	if !tc.conns.current(tc.SQLiteConn) {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (tc *trackedConn) Close() error {
+	tc.conns.closed(tc.SQLiteConn)
+	tc.changes.closed(tc.SQLiteConn)
+	return tc.SQLiteConn.Close()
+}
+
+func (tc *trackedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := tc.SQLiteConn.ExecContext(ctx, query, args)
+	tc.changes.executed(tc.SQLiteConn, query, err)
+	return res, err
+}
+
+func (tc *trackedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := tc.SQLiteConn.QueryContext(ctx, query, args)
+	if err != nil {
+		tc.changes.executed(tc.SQLiteConn, query, err)
+		return nil, err
+	}
+	return &trackedRows{SQLiteRows: rows.(*sqlite3.SQLiteRows), conn: tc, query: query}, nil
+}
+
+func (tc *trackedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := tc.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedStmt{SQLiteStmt: stmt.(*sqlite3.SQLiteStmt), conn: tc, query: query}, nil
+}
+
+// trackedStmt is a statement prepared on a trackedConn.
+type trackedStmt struct {
+	*sqlite3.SQLiteStmt
+	conn  *trackedConn
+	query string
+}
+
+func (ts *trackedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	res, err := ts.SQLiteStmt.ExecContext(ctx, args)
+	ts.conn.changes.executed(ts.conn.SQLiteConn, ts.query, err)
+	return res, err
+}
+
+func (ts *trackedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := ts.SQLiteStmt.QueryContext(ctx, args)
+	if err != nil {
+		ts.conn.changes.executed(ts.conn.SQLiteConn, ts.query, err)
+		return nil, err
+	}
+	return &trackedRows{SQLiteRows: rows.(*sqlite3.SQLiteRows), conn: ts.conn, query: ts.query}, nil
+}
+
+// trackedRows are the rows of a query run on a trackedConn. A statement that writes, such as
+// one with a RETURNING clause, commits while its rows are read, so the change notifier is told
+// once they are closed.
+type trackedRows struct {
+	*sqlite3.SQLiteRows
+	conn  *trackedConn
+	query string
+	err   error
+}
+
+func (tr *trackedRows) Next(dest []driver.Value) error {
+	err := tr.SQLiteRows.Next(dest)
+	if err != nil && err != io.EOF {
+		tr.err = err
+	}
+	return err
+}
+
+func (tr *trackedRows) Close() error {
+	err := tr.SQLiteRows.Close()
+	if tr.err == nil {
+		tr.err = err
+	}
+	tr.conn.changes.executed(tr.conn.SQLiteConn, tr.query, tr.err)
+	return err
+}
+
+// connectHook is called by the driver for every new pooled connection.
+func (sdb *SQLDb) connectHook(conn *sqlite3.SQLiteConn) error {
+	if err := sdb.setEncryptionKey(conn); err != nil {
+		return err
+	}
+	conn.RegisterCommitHook(func() int {
+		sdb.wal.commit()
+		sdb.changes.commit(conn)
+		// Zero allows the commit to proceed.
+		return 0
+	})
+	return sdb.conns.opened(conn)
+}
+
+// sqliteConn returns the sqlite3 connection from a driver connection exposed by sql.Conn.Raw.
+func sqliteConn(driverConn interface{}) *sqlite3.SQLiteConn {
+	if tc, ok := driverConn.(*trackedConn); ok {
+		return tc.SQLiteConn
+	}
+	return driverConn.(*sqlite3.SQLiteConn)
+}
+
+// connections tracks the live connections of the pool, and the setup that is applied to each,
+// so that setup added after the database is opened also reaches the existing connections.
+// The live connections are mapped to whether they are stale, because a pool setup changed
+// after they were opened.
+type connections struct {
+	mu     sync.Mutex
+	live   map[*sqlite3.SQLiteConn]bool
+	setups []connSetup
+}
+
+// connSetup is applied to each connection. Setup with the same name replaces it.
+type connSetup struct {
+	name  string
+	apply func(conn *sqlite3.SQLiteConn) error
+}
+
+func newConnections() *connections {
+	return &connections{live: make(map[*sqlite3.SQLiteConn]bool)}
+}
+
+// addSetup applies the setup to every live connection, and to each new connection after.
+func (c *connections) addSetup(name string, apply func(conn *sqlite3.SQLiteConn) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for conn := range c.live {
+		if err := apply(conn); err != nil {
+			return err
+		}
+	}
+	for i, setup := range c.setups {
+		if setup.name == name {
+			c.setups[i].apply = apply
+			return nil
+		}
+	}
+	c.setups = append(c.setups, connSetup{name: name, apply: apply})
+	return nil
+}
+
+// resetSetup sets the named setup to apply to new connections, or removes it if apply is nil.
+// Unlike addSetup, it does not touch the live connections, which may be in use by other goroutines.
+// They are marked stale instead, so the pool closes them rather than reuse them, and opens new
+// connections with the current setup. Returns whether there was a setup with the name.
+func (c *connections) resetSetup(name string, apply func(conn *sqlite3.SQLiteConn) error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for conn := range c.live {
+		c.live[conn] = true
+	}
+	for i, setup := range c.setups {
+		if setup.name == name {
+			if apply == nil {
+				c.setups = append(c.setups[:i], c.setups[i+1:]...)
+			} else {
+				c.setups[i].apply = apply
+			}
+			return true
+		}
+	}
+	if apply != nil {
+		c.setups = append(c.setups, connSetup{name: name, apply: apply})
+	}
+	return false
+}
+
+// current reports whether the connection has the current pool setup.
+func (c *connections) current(conn *sqlite3.SQLiteConn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.live[conn]
+}
+
+// opened applies the setup to a new connection, and tracks it until it is closed.
+func (c *connections) opened(conn *sqlite3.SQLiteConn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, setup := range c.setups {
+		if err := setup.apply(conn); err != nil {
+			return err
+		}
+	}
+	c.live[conn] = false
+	return nil
+}
+
+func (c *connections) closed(conn *sqlite3.SQLiteConn) {
+	c.mu.Lock()
+	delete(c.live, conn)
+	c.mu.Unlock()
+}
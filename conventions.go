@@ -0,0 +1,119 @@
+package sqldb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TableConventions - The opt-in timestamp and soft-delete columns of a table. InsertStruct,
+// InsertMany, and Upsert fill in the timestamps, and SoftDelete and NotDeleted use the
+// soft-delete column. An empty column name turns its convention off.
+type TableConventions struct {
+	// CreatedAt is set to the current time when a row is inserted.
+	CreatedAt string
+	// UpdatedAt is set to the current time when a row is inserted or updated by an upsert.
+	UpdatedAt string
+	// DeletedAt is set to the current time when a row is soft deleted, and is NULL otherwise.
+	DeletedAt string
+}
+
+// DefaultConventions - The conventional created_at, updated_at, and deleted_at columns.
+var DefaultConventions = TableConventions{
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+	DeletedAt: "deleted_at",
+}
+
+var conventions = struct {
+	sync.RWMutex
+	byTable map[string]TableConventions
+}{byTable: make(map[string]TableConventions)}
+
+// RegisterConventions - Follow the conventions for the table. Registering a table again
+// replaces its conventions.
+func RegisterConventions(table string, conv TableConventions) {
+	conventions.Lock()
+	defer conventions.Unlock()
+	conventions.byTable[table] = conv
+}
+
+// UnregisterConventions - Stop following any conventions for the table.
+func UnregisterConventions(table string) {
+	conventions.Lock()
+	defer conventions.Unlock()
+	delete(conventions.byTable, table)
+}
+
+func lookupConventions(table string) TableConventions {
+	conventions.RLock()
+	defer conventions.RUnlock()
+	return conventions.byTable[table]
+}
+
+// timeNow returns the time stored in the timestamp columns.
+var timeNow = func() time.Time {
+	return time.Now().UTC()
+}
+
+// insertStamps returns the timestamp columns that are set when a row is inserted.
+func (tc TableConventions) insertStamps() []string {
+	var columns []string
+	for _, col := range []string{tc.CreatedAt, tc.UpdatedAt} {
+		if col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// isStamp reports whether the column is one of the timestamp columns.
+func (tc TableConventions) isStamp(column string) bool {
+	return column != "" && (column == tc.CreatedAt || column == tc.UpdatedAt)
+}
+
+// stampColumns returns the insert timestamp columns of the table that are not among the columns.
+func stampColumns(table string, columns []string) []string {
+	var stamps []string
+	for _, stamp := range lookupConventions(table).insertStamps() {
+		if !containsString(columns, stamp) {
+			stamps = append(stamps, stamp)
+		}
+	}
+	return stamps
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// SoftDelete - Mark the rows of the table that match the where clause with the bound arguments
+// as deleted, by setting the soft-delete column of its conventions to the current time.
+// Rows that are already deleted keep their original deletion time.
+func (sdb *SQLDb) SoftDelete(table string, where string, args ...interface{}) error {
+	deletedAt := lookupConventions(table).DeletedAt
+	if deletedAt == "" {
+		return fmt.Errorf("dberror: no soft-delete column registered for %s", table)
+	}
+	return sdb.Exec(fmt.Sprintf("UPDATE %s SET %s = ?%s", table, deletedAt, whereClause(NotDeleted(table, where))),
+		append([]interface{}{timeNow()}, args...)...)
+}
+
+// NotDeleted - Return the where clause with a condition added that excludes the soft-deleted
+// rows of the table, for use with Count, Exists, and other queries of the table.
+// The where clause is returned unchanged if the table has no soft-delete column.
+func NotDeleted(table string, where string) string {
+	deletedAt := lookupConventions(table).DeletedAt
+	switch {
+	case deletedAt == "":
+		return where
+	case where == "":
+		return deletedAt + " IS NULL"
+	}
+	return fmt.Sprintf("(%s) AND %s IS NULL", where, deletedAt)
+}
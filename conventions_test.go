@@ -0,0 +1,86 @@
+package sqldb
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestConventions(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	err := sdb.CreateTable(`testtable (id INTEGER PRIMARY KEY, name TEXT,
+		created_at TIMESTAMP, updated_at TIMESTAMP, deleted_at TIMESTAMP)`)
+	if err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	RegisterConventions("testtable", DefaultConventions)
+	defer UnregisterConventions("testtable")
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer func(orig func() time.Time) { timeNow = orig }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	type row struct {
+		ID   int
+		Name string
+	}
+	if _, err := sdb.InsertStruct("testtable", row{ID: 1, Name: "a"}); err != nil {
+		t.Errorf("InsertStruct error: %v", err)
+	}
+	if err := sdb.InsertMany("testtable", []string{"id", "name"}, [][]interface{}{{2, "b"}, {3, "c"}}); err != nil {
+		t.Errorf("InsertMany error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", "created_at = ? AND updated_at = ?", now, now); err != nil || count != 3 {
+		t.Errorf("Expected 3 stamped rows, but was %v: %v", count, err)
+	}
+
+	// An upsert that updates a row keeps its creation time.
+	later := now.Add(time.Hour)
+	timeNow = func() time.Time { return later }
+	if err := sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"id": 1, "name": "z"}); err != nil {
+		t.Errorf("Upsert error: %v", err)
+	}
+	if err := sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"id": 2}); err != nil {
+		t.Errorf("Upsert key only error: %v", err)
+	}
+	var created, updated time.Time
+	if err := sdb.SingleQuery("SELECT created_at, updated_at FROM testtable WHERE id = 1", &created, &updated); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if !created.Equal(now) || !updated.Equal(later) {
+		t.Errorf("Expected (%v, %v), but was (%v, %v)", now, later, created, updated)
+	}
+	if exists, err := sdb.Exists("testtable", "id = 2 AND updated_at = ?", now); err != nil || !exists {
+		t.Errorf("Expected a key-only upsert to leave the update time: %v, %v", exists, err)
+	}
+
+	if err := sdb.SoftDelete("testtable", "id = ?", 3); err != nil {
+		t.Errorf("SoftDelete error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", NotDeleted("testtable", "")); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows not deleted, but was %v: %v", count, err)
+	}
+	if exists, err := sdb.Exists("testtable", NotDeleted("testtable", "id = ?"), 3); err != nil || exists {
+		t.Errorf("Expected the deleted row to be excluded: %v, %v", exists, err)
+	}
+	var ids []int
+	err = sdb.Select("id").From("testtable").NotDeleted().OrderBy("id").Multi(func(rows *sql.Rows) (bool, error) {
+		var id int
+		err := rows.Scan(&id)
+		ids = append(ids, id)
+		return false, err
+	})
+	if err != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("Expected ids [1 2], but was %v: %v", ids, err)
+	}
+
+	if err := sdb.SoftDelete("notatable", ""); err == nil {
+		t.Error("SoftDelete of a table without conventions did not return an error")
+	}
+	if where := NotDeleted("notatable", "id = 1"); where != "id = 1" {
+		t.Errorf("Expected the where clause unchanged, but was %s", where)
+	}
+}
@@ -0,0 +1,145 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Converter - Convert values of a custom Go type to and from database values, so domain
+// types such as net.IP, decimals, or enums can be bound and scanned directly.
+type Converter struct {
+	// ToDb converts a value of the registered type to a value the driver can bind,
+	// such as an int64, float64, bool, []byte, string, or time.Time.
+	ToDb func(v interface{}) (interface{}, error)
+	// FromDb converts a scanned database value, which may be nil, to a value of the registered type.
+	FromDb func(src interface{}) (interface{}, error)
+}
+
+var converters = struct {
+	sync.RWMutex
+	byType map[reflect.Type]Converter
+}{byType: make(map[reflect.Type]Converter)}
+
+// RegisterConverter - Register the conversions for the type of sample. The conversions are used
+// for bound arguments, SingleQuery and ScanRow destinations, and mapped struct fields.
+// Registering a type again replaces its conversions.
+func RegisterConverter(sample interface{}, conv Converter) {
+	converters.Lock()
+	defer converters.Unlock()
+	converters.byType[reflect.TypeOf(sample)] = conv
+}
+
+// UnregisterConverter - Remove the conversions for the type of sample.
+func UnregisterConverter(sample interface{}) {
+	converters.Lock()
+	defer converters.Unlock()
+	delete(converters.byType, reflect.TypeOf(sample))
+}
+
+func lookupConverter(t reflect.Type) (Converter, bool) {
+	converters.RLock()
+	defer converters.RUnlock()
+	conv, ok := converters.byType[t]
+	return conv, ok
+}
+
+// convertArgs converts any bound arguments of a registered type to database values, and
+// 16 byte arrays, such as uuid.UUID, to blobs, as they are for struct fields.
+func convertArgs(args []interface{}) ([]interface{}, error) {
+	var converted []interface{}
+	for i, arg := range args {
+		if arg == nil {
+			continue
+		}
+		t := reflect.TypeOf(arg)
+		conv, ok := lookupConverter(t)
+		if (ok && conv.ToDb == nil) || (!ok && !isUUIDType(t)) {
+			continue
+		}
+		if converted == nil {
+			// Copy on first conversion, leaving the caller's arguments untouched.
+			converted = append([]interface{}(nil), args...)
+		}
+		if !ok {
+			converted[i] = uuidBlob(reflect.ValueOf(arg))
+			continue
+		}
+		v, err := conv.ToDb(arg)
+		if err != nil {
+			return nil, fmt.Errorf("dberror: converting argument %d of type %T: %v", i, arg, err)
+		}
+		converted[i] = v
+	}
+	if converted == nil {
+		return args, nil
+	}
+	return converted, nil
+}
+
+// convertDest wraps any scan destinations that point to a registered type, or to a 16 byte array.
+func convertDest(dest []interface{}) []interface{} {
+	var converted []interface{}
+	for i, d := range dest {
+		v := reflect.ValueOf(d)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			continue
+		}
+		scanner, ok := converterScanner(v.Elem())
+		if !ok && isUUIDType(v.Elem().Type()) {
+			scanner, ok = uuidScanner{v: v.Elem()}, true
+		}
+		if ok {
+			if converted == nil {
+				converted = append([]interface{}(nil), dest...)
+			}
+			converted[i] = scanner
+		}
+	}
+	if converted == nil {
+		return dest
+	}
+	return converted
+}
+
+// converterScanner returns a scanner that converts into v if its type is registered.
+func converterScanner(v reflect.Value) (sql.Scanner, bool) {
+	conv, ok := lookupConverter(v.Type())
+	if !ok || conv.FromDb == nil {
+		return nil, false
+	}
+	return convScanner{v: v, conv: conv}, true
+}
+
+type convScanner struct {
+	v    reflect.Value
+	conv Converter
+}
+
+func (cs convScanner) Scan(src interface{}) error {
+	// The driver may reuse the memory of scanned bytes.
+	if b, ok := src.([]byte); ok {
+		src = append([]byte(nil), b...)
+	}
+	val, err := cs.conv.FromDb(src)
+	if err != nil {
+		return fmt.Errorf("dberror: converting %T to %s: %v", src, cs.v.Type(), err)
+	}
+	if val == nil {
+		cs.v.Set(reflect.Zero(cs.v.Type()))
+		return nil
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.Type().AssignableTo(cs.v.Type()) {
+		return fmt.Errorf("dberror: converter returned %T, expected %s", val, cs.v.Type())
+	}
+	cs.v.Set(rv)
+	return nil
+}
+
+// ScanRow - Scan the current row into dest, applying registered conversions.
+// Use in place of rows.Scan inside MultiQuery actions.
+func ScanRow(rows *sql.Rows, dest ...interface{}) error {
+	return rows.Scan(convertDest(dest)...)
+}
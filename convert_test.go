@@ -0,0 +1,137 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"testing"
+)
+
+type testColor int
+
+const (
+	testRed testColor = iota
+	testGreen
+)
+
+var testColorNames = []string{"red", "green"}
+
+func registerTestConverters() {
+	RegisterConverter(net.IP{}, Converter{
+		ToDb: func(v interface{}) (interface{}, error) {
+			return v.(net.IP).String(), nil
+		},
+		FromDb: func(src interface{}) (interface{}, error) {
+			if src == nil {
+				return nil, nil
+			}
+			ip := net.ParseIP(fmt.Sprint(src))
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %v", src)
+			}
+			return ip, nil
+		},
+	})
+	RegisterConverter(testRed, Converter{
+		ToDb: func(v interface{}) (interface{}, error) {
+			return testColorNames[v.(testColor)], nil
+		},
+		FromDb: func(src interface{}) (interface{}, error) {
+			for i, name := range testColorNames {
+				if name == src {
+					return testColor(i), nil
+				}
+			}
+			return nil, fmt.Errorf("unknown color %v", src)
+		},
+	})
+}
+
+func unregisterTestConverters() {
+	UnregisterConverter(net.IP{})
+	UnregisterConverter(testRed)
+}
+
+type testHost struct {
+	Name  string
+	Addr  net.IP
+	Color testColor
+}
+
+func TestConverters(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	registerTestConverters()
+	defer unregisterTestConverters()
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (name TEXT, addr TEXT, color TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	err := sdb.Exec("INSERT INTO testtable (name, addr, color) VALUES (?, ?, ?)", "a", net.ParseIP("10.0.0.1"), testGreen)
+	if err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	host := testHost{Name: "b", Addr: net.ParseIP("::1"), Color: testRed}
+	if _, err := sdb.InsertStruct("testtable", host); err != nil {
+		t.Fatalf("InsertStruct error: %v", err)
+	}
+
+	// Values are stored in their database form.
+	var color string
+	if err := sdb.SingleQuery("SELECT color FROM testtable WHERE name = 'a'", &color); err != nil || color != "green" {
+		t.Errorf("Expected stored color green, but was %v: %v", color, err)
+	}
+
+	var addr net.IP
+	var c testColor
+	if err := sdb.SingleQuery("SELECT addr, color FROM testtable WHERE name = 'a'", &addr, &c); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if !addr.Equal(net.ParseIP("10.0.0.1")) || c != testGreen {
+		t.Errorf("Expected (10.0.0.1, green), but was (%v, %v)", addr, c)
+	}
+
+	exists, err := sdb.QueryExists("SELECT name FROM testtable WHERE color = ?", testRed)
+	if err != nil || !exists {
+		t.Errorf("Expected converted argument to match: %v, %v", exists, err)
+	}
+
+	var hosts []testHost
+	if err := sdb.QueryStructs(&hosts, "SELECT * FROM testtable WHERE name = ?", "b"); err != nil {
+		t.Fatalf("QueryStructs error: %v", err)
+	}
+	if len(hosts) != 1 || !hosts[0].Addr.Equal(host.Addr) || hosts[0].Color != testRed {
+		t.Errorf("Expected %v, but was %v", host, hosts)
+	}
+
+	var scanned []net.IP
+	err = sdb.MultiQuery("SELECT addr FROM testtable ORDER BY name", func(rows *sql.Rows) error {
+		var ip net.IP
+		if err := ScanRow(rows, &ip); err != nil {
+			return err
+		}
+		scanned = append(scanned, ip)
+		return nil
+	})
+	if err != nil || len(scanned) != 2 {
+		t.Errorf("Expected 2 scanned addresses, but was %v: %v", scanned, err)
+	}
+}
+
+func TestConverters_FromDbError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	registerTestConverters()
+	defer unregisterTestConverters()
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	var c testColor
+	if err := sdb.SingleQuery("SELECT 'purple'", &c); err == nil {
+		t.Error("SingleQuery did not return a conversion error")
+	}
+}
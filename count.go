@@ -0,0 +1,29 @@
+package sqldb
+
+import (
+	"fmt"
+)
+
+// Count - Return the number of rows of the table that match the where clause with the bound
+// arguments, such as Count("users", "age > ?", 21). An empty where clause counts every row.
+func (sdb *SQLDb) Count(table string, where string, args ...interface{}) (int64, error) {
+	var count int64
+	if err := sdb.SingleQueryArgs(fmt.Sprintf("SELECT COUNT(*) FROM %s%s", table, whereClause(where)), args, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Exists - Report whether any row of the table matches the where clause with the bound arguments.
+// An empty where clause reports whether the table has any rows.
+func (sdb *SQLDb) Exists(table string, where string, args ...interface{}) (bool, error) {
+	return sdb.QueryExists(fmt.Sprintf("SELECT 1 FROM %s%s LIMIT 1", table, whereClause(where)), args...)
+}
+
+// whereClause returns the WHERE clause for the condition, or nothing for an empty condition.
+func whereClause(where string) string {
+	if where == "" {
+		return ""
+	}
+	return " WHERE " + where
+}
@@ -0,0 +1,42 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+func TestCountExists(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	if count, err := sdb.Count("testtable", ""); err != nil || count != 0 {
+		t.Errorf("Expected 0 rows, but was %v: %v", count, err)
+	}
+	if exists, err := sdb.Exists("testtable", ""); err != nil || exists {
+		t.Errorf("Expected no rows, but was %v: %v", exists, err)
+	}
+
+	if err := sdb.Exec("INSERT INTO testtable (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'b')"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", ""); err != nil || count != 3 {
+		t.Errorf("Expected 3 rows, but was %v: %v", count, err)
+	}
+	if count, err := sdb.Count("testtable", "name = ?", "b"); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, but was %v: %v", count, err)
+	}
+	if exists, err := sdb.Exists("testtable", "id = ?", 3); err != nil || !exists {
+		t.Errorf("Expected a row, but was %v: %v", exists, err)
+	}
+	if exists, err := sdb.Exists("testtable", "id = ?", 4); err != nil || exists {
+		t.Errorf("Expected no row, but was %v: %v", exists, err)
+	}
+	if _, err := sdb.Count("notatable", ""); err == nil {
+		t.Error("Count of a missing table did not return an error")
+	}
+}
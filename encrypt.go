@@ -0,0 +1,88 @@
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// The header at the start of every unencrypted SQLite database file.
+var sqliteFileHeader = []byte("SQLite format 3\x00")
+
+// ErrEncryptionUnsupported is returned when an encryption key is used without SQLCipher support.
+var ErrEncryptionUnsupported = errors.New("dberror: encryption requires building with the sqlcipher tag")
+
+// WithEncryptionKey - Encrypt the database with the key, using SQLCipher. The key is set on
+// every connection before it is used. Requires building with the sqlcipher tag and linking
+// against a SQLCipher library, such as with -tags "libsqlite3 sqlcipher" and
+// CGO_LDFLAGS=-lsqlcipher. Otherwise, opening the database fails with ErrEncryptionUnsupported.
+func WithEncryptionKey(key string) Option {
+	return func(sdb *SQLDb) {
+		sdb.encryptionKey = key
+	}
+}
+
+// Rekey - Re-encrypt the database with the new key. The database handle is reopened with it.
+func (sdb *SQLDb) Rekey(newKey string) error {
+	if !encryptionSupported {
+		return ErrEncryptionUnsupported
+	}
+	conn, err := sdb.DB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA rekey = %s", quoteKey(newKey)))
+	conn.Close()
+	if err != nil {
+		return fmt.Errorf("dberror: rekeying database: %v", err)
+	}
+	// Connections opened with the old key can no longer read the database.
+	if err := sdb.closeDB(); err != nil {
+		return err
+	}
+	sdb.encryptionKey = newKey
+	return sdb.open()
+}
+
+// IsEncrypted - Report whether the database file is encrypted, by checking for the plain
+// SQLite file header. A new database that has not been written yet is not encrypted.
+func (sdb *SQLDb) IsEncrypted() (bool, error) {
+	path := dbFilePath(sdb.filename)
+	if path == "" {
+		return false, ErrNotFileDb
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteFileHeader))
+	n, err := io.ReadFull(f, header)
+	if n == 0 && err == io.EOF {
+		return false, nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return !bytes.Equal(header[:n], sqliteFileHeader), nil
+}
+
+// setEncryptionKey sets the key on a new connection, before anything else uses it.
+func (sdb *SQLDb) setEncryptionKey(conn *sqlite3.SQLiteConn) error {
+	if sdb.encryptionKey == "" {
+		return nil
+	}
+	_, err := conn.Exec(fmt.Sprintf("PRAGMA key = %s", quoteKey(sdb.encryptionKey)), nil)
+	return err
+}
+
+// quoteKey returns the key, or other text, as an SQL string literal.
+func quoteKey(key string) string {
+	return "'" + strings.ReplaceAll(key, "'", "''") + "'"
+}
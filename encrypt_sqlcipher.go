@@ -0,0 +1,6 @@
+//go:build sqlcipher
+
+package sqldb
+
+// Encryption is supported when built with the sqlcipher tag against a SQLCipher library.
+const encryptionSupported = true
@@ -0,0 +1,56 @@
+//go:build !sqlcipher
+
+package sqldb
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestWithEncryptionKey_Unsupported(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	if _, err := OpenDb(testDbName, WithEncryptionKey("secret")); !errors.Is(err, ErrEncryptionUnsupported) {
+		t.Errorf("Expected ErrEncryptionUnsupported, but was %v", err)
+	}
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.Rekey("secret"); !errors.Is(err, ErrEncryptionUnsupported) {
+		t.Errorf("Expected ErrEncryptionUnsupported, but was %v", err)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if encrypted, err := sdb.IsEncrypted(); err != nil || encrypted {
+		t.Errorf("Expected new database to not be encrypted: %v, %v", encrypted, err)
+	}
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if encrypted, err := sdb.IsEncrypted(); err != nil || encrypted {
+		t.Errorf("Expected database to not be encrypted: %v, %v", encrypted, err)
+	}
+
+	// An encrypted file has no plain header.
+	if err := os.WriteFile(testDbName+".enc", []byte("\x8f\x12random page data that is not a header"), 0o600); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	defer os.Remove(testDbName + ".enc")
+	encDb := &SQLDb{filename: testDbName + ".enc"}
+	if encrypted, err := encDb.IsEncrypted(); err != nil || !encrypted {
+		t.Errorf("Expected file to be encrypted: %v, %v", encrypted, err)
+	}
+
+	memDb := &SQLDb{filename: ":memory:"}
+	if _, err := memDb.IsEncrypted(); !errors.Is(err, ErrNotFileDb) {
+		t.Errorf("Expected ErrNotFileDb, but was %v", err)
+	}
+}
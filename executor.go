@@ -0,0 +1,34 @@
+package sqldb
+
+import (
+	"database/sql"
+)
+
+// DBExecutor - The statements, queries, transactions, and save points of a database.
+// Code written against it works with a database or inside a transaction, and can be
+// tested with a mock. The sqldbtest helpers that only run statements and queries accept it.
+// Patch and seed functions are passed an *SQLDb, and the table and schema helpers, such as
+// RebuildTable and CopyTable, are methods of it, since they use more of the database than
+// the interface covers.
+type DBExecutor interface {
+	Exec(stmt string, args ...interface{}) error
+	ExecScript(script string) error
+	ExecResults(stmt string, args ...interface{}) (sql.Result, error)
+	SingleQuery(stmt string, args ...interface{}) error
+	SingleQueryArgs(stmt string, args []interface{}, dest ...interface{}) error
+	MultiQuery(stmt string, action func(rows *sql.Rows) error) error
+	MultiQueryArgs(stmt string, args []interface{}, action func(rows *sql.Rows) (stop bool, err error)) error
+	QueryExists(stmt string, args ...interface{}) (bool, error)
+
+	BeginTrans() error
+	CommitTrans() error
+	RollbackTrans() error
+
+	CreateSavePoint(name string) error
+	CommitSavePoint(name string) error
+	RollbackSavePoint(name string) error
+	ExecWithSavePoint(spName string, fn func() error) error
+	NestedTxn(fn func() error) error
+}
+
+var _ DBExecutor = (*SQLDb)(nil)
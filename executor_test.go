@@ -0,0 +1,64 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+// testAddUser is application code written against the interface.
+func testAddUser(db DBExecutor, name string) error {
+	return db.NestedTxn(func() error {
+		exists, err := db.QueryExists("SELECT name FROM users WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("duplicate user")
+		}
+		return db.Exec("INSERT INTO users (name) VALUES (?)", name)
+	})
+}
+
+// testFailingExecutor is a mock database whose statements always fail.
+type testFailingExecutor struct {
+	DBExecutor
+	execs int
+}
+
+func (fe *testFailingExecutor) NestedTxn(fn func() error) error {
+	return fn()
+}
+
+func (fe *testFailingExecutor) QueryExists(stmt string, args ...interface{}) (bool, error) {
+	return false, nil
+}
+
+func (fe *testFailingExecutor) Exec(stmt string, args ...interface{}) error {
+	fe.execs++
+	return errors.New("disk full")
+}
+
+func TestDBExecutor(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("users (name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	if err := testAddUser(sdb, "a"); err != nil {
+		t.Errorf("testAddUser error: %v", err)
+	}
+	if err := sdb.WithTransaction(func(tx *SQLDb) error {
+		return testAddUser(tx, "a")
+	}); err == nil {
+		t.Error("Expected duplicate user error")
+	}
+
+	mock := &testFailingExecutor{}
+	if err := testAddUser(mock, "b"); err == nil || mock.execs != 1 {
+		t.Errorf("Expected mock Exec error, but was %v after %v execs", err, mock.execs)
+	}
+}
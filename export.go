@@ -0,0 +1,425 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The number of imported rows inserted by each InsertMany call.
+const importBatchRows = 1000
+
+// Format - Serialization format for exporting and importing tables.
+type Format int
+
+const (
+	// FormatCSV is comma separated values with a header row of column names. To keep the type
+	// of each value, NULL values are written as \N, BLOB values as \x followed by hex digits,
+	// and REAL values always with a decimal point or exponent. Text that starts with a
+	// backslash, or that would be read as a number, is escaped with a leading backslash.
+	// Unescaped fields that are numbers are imported as numbers, and other fields as text.
+	FormatCSV Format = iota
+	// FormatJSONLines is one JSON object per line, keyed by column name. BLOB values are
+	// written as objects with the base64 encoded blob under the "$blob" key, and REAL values
+	// always with a decimal point or exponent. Other objects and arrays are imported as JSON text.
+	FormatJSONLines
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSONLines:
+		return "jsonl"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ExportTable - Write every row of the table to w in the given format.
+func (sdb *SQLDb) ExportTable(w io.Writer, table string, format Format) error {
+	var writeRow func(columns []string, values []interface{}) error
+	var flush func() error
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		header := true
+		writeRow = func(columns []string, values []interface{}) error {
+			if header {
+				header = false
+				if err := cw.Write(columns); err != nil {
+					return err
+				}
+			}
+			record := make([]string, len(values))
+			for i, v := range values {
+				record[i] = csvValue(v)
+			}
+			return cw.Write(record)
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatJSONLines:
+		enc := json.NewEncoder(w)
+		writeRow = func(columns []string, values []interface{}) error {
+			record := make(map[string]interface{}, len(columns))
+			for i, column := range columns {
+				record[column] = jsonValue(values[i])
+			}
+			return enc.Encode(record)
+		}
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("dberror: unsupported export format %v", format)
+	}
+
+	stmt := fmt.Sprintf("SELECT * FROM %s", table)
+	err := sdb.MultiQuery(stmt, func(rows *sql.Rows) error {
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		return writeRow(columns, values)
+	})
+	if err != nil {
+		return fmt.Errorf("dberror: exporting %s: %w", table, err)
+	}
+	return flush()
+}
+
+// ImportTable - Insert the rows read from r in the given format into the table.
+// Either all rows are imported, or none are.
+func (sdb *SQLDb) ImportTable(r io.Reader, table string, format Format) error {
+	return sdb.ImportTableColumns(r, table, format, nil)
+}
+
+// ImportTableColumns - Insert the rows read from r in the given format into the table,
+// renaming fields to table columns with the column map. Fields that are not in the map
+// keep their name, and fields mapped to "-" are skipped. Either all rows are imported,
+// or none are.
+func (sdb *SQLDb) ImportTableColumns(r io.Reader, table string, format Format, columnMap map[string]string) error {
+	var reader importReader
+	switch format {
+	case FormatCSV:
+		reader = newCSVImportReader(r)
+	case FormatJSONLines:
+		reader = newJSONImportReader(r)
+	default:
+		return fmt.Errorf("dberror: unsupported import format %v", format)
+	}
+
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		for offset := 0; ; {
+			fields, rows, err := reader.readBatch(importBatchRows)
+			if err != nil {
+				return fmt.Errorf("dberror: importing %s: %w", table, err)
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			columns, rows := mapImportColumns(fields, rows, columnMap)
+			if err := tx.InsertMany(table, columns, rows); err != nil {
+				// Report failed rows by their position in the whole import.
+				return offsetMultiError(err, offset)
+			}
+			offset += len(rows)
+		}
+	})
+}
+
+// mapImportColumns renames the fields to columns, and drops skipped fields from the rows.
+func mapImportColumns(fields []string, rows [][]interface{}, columnMap map[string]string) ([]string, [][]interface{}) {
+	if columnMap == nil {
+		return fields, rows
+	}
+	var columns []string
+	var keep []int
+	for i, field := range fields {
+		column, ok := columnMap[field]
+		if !ok {
+			column = field
+		}
+		if column == "-" {
+			continue
+		}
+		columns = append(columns, column)
+		keep = append(keep, i)
+	}
+	if len(keep) == len(fields) {
+		return columns, rows
+	}
+	mapped := make([][]interface{}, len(rows))
+	for r, row := range rows {
+		mapped[r] = make([]interface{}, len(keep))
+		for i, k := range keep {
+			mapped[r][i] = row[k]
+		}
+	}
+	return columns, mapped
+}
+
+// The CSV field of a NULL value, and the prefix of the CSV fields of BLOB values.
+const (
+	csvNull       = `\N`
+	csvBlobPrefix = `\x`
+)
+
+// The key of the JSON object that holds a BLOB value.
+const jsonBlobKey = "$blob"
+
+// csvValue formats a column value as a CSV field that csvImportValue reads back with the same type.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return csvNull
+	case []byte:
+		return csvBlobPrefix + hex.EncodeToString(v)
+	case string:
+		if _, isNumber := parseNumber(v); isNumber || strings.HasPrefix(v, `\`) {
+			return `\` + v
+		}
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return formatReal(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// csvImportValue parses a CSV field written by csvValue.
+func csvImportValue(field string) (interface{}, error) {
+	switch {
+	case field == csvNull:
+		return nil, nil
+	case strings.HasPrefix(field, csvBlobPrefix):
+		return hex.DecodeString(field[len(csvBlobPrefix):])
+	case strings.HasPrefix(field, `\`):
+		return field[1:], nil
+	}
+	if n, isNumber := parseNumber(field); isNumber {
+		return n, nil
+	}
+	return field, nil
+}
+
+// parseNumber parses text that is an integer or a real number.
+func parseNumber(s string) (interface{}, bool) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// formatReal formats a real number so that it is not read back as an integer.
+func formatReal(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEnN") {
+		s += ".0"
+	}
+	return s
+}
+
+// jsonValue returns the value to encode as JSON for a column value.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return map[string]string{jsonBlobKey: base64.StdEncoding.EncodeToString(v)}
+	case float64:
+		// JSON has no infinities or NaN, so they are left for the encoder to reject.
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return v
+		}
+		return json.Number(formatReal(v))
+	}
+	return v
+}
+
+// importReader reads batches of rows to import. All rows in a batch have a value for each field.
+type importReader interface {
+	readBatch(maxRows int) (fields []string, rows [][]interface{}, err error)
+}
+
+type csvImportReader struct {
+	cr     *csv.Reader
+	header []string
+}
+
+func newCSVImportReader(r io.Reader) *csvImportReader {
+	return &csvImportReader{cr: csv.NewReader(r)}
+}
+
+func (cir *csvImportReader) readBatch(maxRows int) ([]string, [][]interface{}, error) {
+	if cir.header == nil {
+		header, err := cir.cr.Read()
+		if err == io.EOF {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		cir.header = header
+	}
+	var rows [][]interface{}
+	for len(rows) < maxRows {
+		record, err := cir.cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make([]interface{}, len(record))
+		for i, field := range record {
+			if row[i], err = csvImportValue(field); err != nil {
+				line, _ := cir.cr.FieldPos(i)
+				return nil, nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return cir.header, rows, nil
+}
+
+type jsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONImportReader(r io.Reader) *jsonImportReader {
+	scanner := bufio.NewScanner(r)
+	// Allow for large rows, such as documents and encoded blobs.
+	scanner.Buffer(nil, 64*1024*1024)
+	return &jsonImportReader{scanner: scanner}
+}
+
+func (jir *jsonImportReader) readBatch(maxRows int) ([]string, [][]interface{}, error) {
+	var fields []string
+	index := make(map[string]int)
+	var records []map[string]interface{}
+	for len(records) < maxRows && jir.scanner.Scan() {
+		jir.line++
+		line := jir.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		record, err := decodeJSONRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", jir.line, err)
+		}
+		// Fields are the union of the record keys, in the order first seen.
+		for _, field := range sortedKeys(record) {
+			if _, ok := index[field]; !ok {
+				index[field] = len(fields)
+				fields = append(fields, field)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := jir.scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows := make([][]interface{}, len(records))
+	for r, record := range records {
+		rows[r] = make([]interface{}, len(fields))
+		for field, v := range record {
+			rows[r][index[field]] = v
+		}
+	}
+	return fields, rows, nil
+}
+
+// decodeJSONRecord decodes a JSON object into column values. Numbers keep their integer
+// precision, blob objects written by jsonValue are decoded, and other objects and arrays are
+// stored as JSON text.
+func decodeJSONRecord(line []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, err
+	}
+	record := make(map[string]interface{}, len(raw))
+	for field, msg := range raw {
+		var v interface{}
+		switch msg[0] {
+		case '{', '[':
+			if blob, ok := decodeJSONBlob(msg); ok {
+				v = blob
+			} else {
+				v = string(msg)
+			}
+		case '"', 't', 'f', 'n':
+			if err := json.Unmarshal(msg, &v); err != nil {
+				return nil, err
+			}
+		default:
+			n := json.Number(msg)
+			if i, err := n.Int64(); err == nil {
+				v = i
+			} else if f, err := n.Float64(); err == nil {
+				v = f
+			} else {
+				return nil, err
+			}
+		}
+		record[field] = v
+	}
+	return record, nil
+}
+
+// decodeJSONBlob decodes a blob object written by jsonValue.
+func decodeJSONBlob(msg json.RawMessage) ([]byte, bool) {
+	var obj map[string]string
+	if err := json.Unmarshal(msg, &obj); err != nil || len(obj) != 1 {
+		return nil, false
+	}
+	encoded, ok := obj[jsonBlobKey]
+	if !ok {
+		return nil, false
+	}
+	blob, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	return blob, true
+}
+
+// sortedKeys returns the keys of the record in sorted order.
+func sortedKeys(record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,160 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func createExportTestTable(t *testing.T, sdb *SQLDb) {
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 TEXT, field2 REAL)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+}
+
+func TestExportImportTable(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatJSONLines} {
+		t.Run(format.String(), func(t *testing.T) {
+			setupTests(t)
+			defer cleanupTests(t)
+
+			sdb := openTestDb(t)
+			defer closeDb(t, &sdb)
+			createExportTestTable(t, sdb)
+			err := sdb.InsertMany("testtable", []string{"id", "field1", "field2"}, [][]interface{}{
+				{1, "a,b", 1.5},
+				{2, "line\nbreak", nil},
+				{3, nil, 2.25},
+			})
+			if err != nil {
+				t.Fatalf("InsertMany error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := sdb.ExportTable(&buf, "testtable", format); err != nil {
+				t.Fatalf("ExportTable error: %v", err)
+			}
+			if err := sdb.CreateTable("importtable (id INTEGER, field1 TEXT, field2 REAL)"); err != nil {
+				t.Fatalf("CreateTable error: %v", err)
+			}
+			if err := sdb.ImportTable(&buf, "importtable", format); err != nil {
+				t.Fatalf("ImportTable error: %v", err)
+			}
+
+			var differences int
+			err = sdb.SingleQuery(`SELECT COUNT(*) FROM (
+				SELECT * FROM testtable EXCEPT SELECT * FROM importtable
+				UNION ALL
+				SELECT * FROM importtable EXCEPT SELECT * FROM testtable)`, &differences)
+			if err != nil {
+				t.Fatalf("SingleQuery error: %v", err)
+			}
+			if differences != 0 {
+				t.Errorf("Expected imported table to match exported table, but had %v differences", differences)
+			}
+		})
+	}
+}
+
+func TestExportImportTable_Types(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatJSONLines} {
+		t.Run(format.String(), func(t *testing.T) {
+			setupTests(t)
+			defer cleanupTests(t)
+
+			sdb := openTestDb(t)
+			defer closeDb(t, &sdb)
+			// Columns without a type keep the type of each value as it is stored.
+			if err := sdb.CreateTable("testtable (id INTEGER, value)"); err != nil {
+				t.Fatalf("CreateTable error: %v", err)
+			}
+			err := sdb.InsertMany("testtable", []string{"id", "value"}, [][]interface{}{
+				{1, nil},
+				{2, ""},
+				{3, "42"},
+				{4, 42},
+				{5, 2.0},
+				{6, "2.5"},
+				{7, []byte{0, 1, '\\', 'N', 0xff}},
+				{8, []byte{}},
+				{9, `\N`},
+				{10, `\x00`},
+				{11, `{"$blob":"AAE="}`},
+				{12, "text"},
+			})
+			if err != nil {
+				t.Fatalf("InsertMany error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := sdb.ExportTable(&buf, "testtable", format); err != nil {
+				t.Fatalf("ExportTable error: %v", err)
+			}
+			if err := sdb.CreateTable("importtable (id INTEGER, value)"); err != nil {
+				t.Fatalf("CreateTable error: %v", err)
+			}
+			if err := sdb.ImportTable(&buf, "importtable", format); err != nil {
+				t.Fatalf("ImportTable error: %v", err)
+			}
+
+			var differences int
+			err = sdb.SingleQuery(`SELECT COUNT(*) FROM (
+				SELECT id, value, typeof(value) FROM testtable EXCEPT SELECT id, value, typeof(value) FROM importtable
+				UNION ALL
+				SELECT id, value, typeof(value) FROM importtable EXCEPT SELECT id, value, typeof(value) FROM testtable)`, &differences)
+			if err != nil {
+				t.Fatalf("SingleQuery error: %v", err)
+			}
+			if differences != 0 {
+				t.Errorf("Expected imported values and types to match exported ones, but had %v differences", differences)
+			}
+		})
+	}
+}
+
+func TestImportTableColumns(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	createExportTestTable(t, sdb)
+
+	input := `{"key": 1, "name": "a", "extra": true}
+{"key": 2, "name": "b", "extra": {"nested": 1}}
+`
+	err := sdb.ImportTableColumns(strings.NewReader(input), "testtable", FormatJSONLines,
+		map[string]string{"key": "id", "name": "field1", "extra": "-"})
+	if err != nil {
+		t.Fatalf("ImportTableColumns error: %v", err)
+	}
+
+	var count int
+	var field1 string
+	if err := sdb.SingleQuery("SELECT COUNT(*), MAX(field1) FROM testtable", &count, &field1); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if count != 2 || field1 != "b" {
+		t.Errorf("Expected 2 rows with max b, but was %v, %v", count, field1)
+	}
+}
+
+func TestImportTable_RollbackOnError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	createExportTestTable(t, sdb)
+
+	input := "id,field1\n1,a\n2,b,extra\n"
+	if err := sdb.ImportTable(strings.NewReader(input), "testtable", FormatCSV); err == nil {
+		t.Error("ImportTable did not return an error for a malformed row")
+	}
+	exists, err := sdb.QueryExists("SELECT id FROM testtable")
+	if err != nil || exists {
+		t.Errorf("Expected import to be rolled back: %v, %v", exists, err)
+	}
+}
@@ -0,0 +1,192 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// The number of tokens in a search snippet unless overridden.
+const defaultSnippetTokens = 10
+
+// FTSOptions - Configure a full-text search table created by CreateFTSTable.
+type FTSOptions struct {
+	// ContentTable is the table whose rows are indexed. The FTS table then stores only the
+	// index, and triggers keep it in sync as content rows are inserted, updated, and deleted.
+	// Its columns must have the same names as the FTS columns. Without a content table, the
+	// FTS table stores its own rows.
+	ContentTable string
+	// ContentRowID is the integer primary key column of the content table. Defaults to rowid.
+	ContentRowID string
+	// Tokenizer is the FTS5 tokenizer definition, such as "porter unicode61".
+	Tokenizer string
+	// Prefix is the prefix index lengths, such as "2 3", for faster prefix queries.
+	Prefix string
+}
+
+// FTSMatch - A row that matches a full-text search.
+type FTSMatch struct {
+	// RowID is the rowid of the matching row, which is the content row ID for
+	// a table with a content table.
+	RowID int64
+	// Rank is the FTS5 rank of the match. Better matches have lower ranks.
+	Rank float64
+	// Snippet is the requested snippet of the matching text.
+	Snippet string
+	// Highlight is the requested column text with the matching terms marked.
+	Highlight string
+}
+
+// SearchOption - Configure a full-text search.
+type SearchOption func(cfg *searchConfig)
+
+type searchConfig struct {
+	limit         int
+	snippet       bool
+	snippetColumn int
+	snippetTokens int
+	highlight     int
+	start, end    string
+}
+
+// WithSearchLimit - Return at most n of the best matches.
+func WithSearchLimit(n int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.limit = n
+	}
+}
+
+// WithSearchSnippet - Return a snippet of up to the given number of tokens from the
+// column, by index, around the matching terms. A column of -1 picks the best column.
+func WithSearchSnippet(column, tokens int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.snippet = true
+		cfg.snippetColumn = column
+		cfg.snippetTokens = tokens
+	}
+}
+
+// WithSearchHighlight - Return the full text of the column, by index, with the matching terms marked.
+func WithSearchHighlight(column int) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.highlight = column
+	}
+}
+
+// WithSearchMarkers - Mark matching terms in snippets and highlights with the start and
+// end text, such as "<b>" and "</b>". The defaults are "[" and "]".
+func WithSearchMarkers(start, end string) SearchOption {
+	return func(cfg *searchConfig) {
+		cfg.start = start
+		cfg.end = end
+	}
+}
+
+// CreateFTSTable - Create an FTS5 full-text search table with the columns. With a content
+// table, it also creates the triggers that keep the index in sync, and indexes the existing
+// content rows. Requires SQLite to be built with FTS5, which go-sqlite3 enables with the
+// sqlite_fts5 build tag.
+func (sdb *SQLDb) CreateFTSTable(name string, columns []string, opts FTSOptions) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("dberror: no columns for full-text search table %s", name)
+	}
+	args := append([]string{}, columns...)
+	rowid := "rowid"
+	if opts.ContentTable != "" {
+		args = append(args, fmt.Sprintf("content='%s'", opts.ContentTable))
+		if opts.ContentRowID != "" {
+			rowid = opts.ContentRowID
+			args = append(args, fmt.Sprintf("content_rowid='%s'", rowid))
+		}
+	}
+	if opts.Tokenizer != "" {
+		args = append(args, fmt.Sprintf("tokenize='%s'", opts.Tokenizer))
+	}
+	if opts.Prefix != "" {
+		args = append(args, fmt.Sprintf("prefix='%s'", opts.Prefix))
+	}
+
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		if err := tx.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(%s)", name, strings.Join(args, ", "))); err != nil {
+			return err
+		}
+		if opts.ContentTable == "" {
+			return nil
+		}
+		cols := strings.Join(columns, ", ")
+		newCols := "new." + strings.Join(columns, ", new.")
+		oldCols := "old." + strings.Join(columns, ", old.")
+		insert := fmt.Sprintf("INSERT INTO %s (rowid, %s) VALUES (new.%s, %s);", name, cols, rowid, newCols)
+		// External content tables are updated by deleting the old values from the index.
+		remove := fmt.Sprintf("INSERT INTO %s (%s, rowid, %s) VALUES ('delete', old.%s, %s);", name, name, cols, rowid, oldCols)
+		for _, trigger := range []string{
+			fmt.Sprintf("CREATE TRIGGER %s_ai AFTER INSERT ON %s BEGIN %s END", name, opts.ContentTable, insert),
+			fmt.Sprintf("CREATE TRIGGER %s_ad AFTER DELETE ON %s BEGIN %s END", name, opts.ContentTable, remove),
+			fmt.Sprintf("CREATE TRIGGER %s_au AFTER UPDATE ON %s BEGIN %s %s END", name, opts.ContentTable, remove, insert),
+		} {
+			if err := tx.Exec(trigger); err != nil {
+				return err
+			}
+		}
+		return tx.RebuildFTSTable(name)
+	})
+}
+
+// RebuildFTSTable - Rebuild the full-text index of the table from its content table.
+func (sdb *SQLDb) RebuildFTSTable(name string) error {
+	return sdb.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES ('rebuild')", name, name))
+}
+
+// DropFTSTable - Drop the full-text search table and its sync triggers, if they exist.
+func (sdb *SQLDb) DropFTSTable(name string) error {
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		for _, suffix := range []string{"_ai", "_ad", "_au"} {
+			if err := tx.DropTrigger(name + suffix); err != nil {
+				return err
+			}
+		}
+		return tx.DropTable(name)
+	})
+}
+
+// SearchFTS - Search the full-text search table with the FTS5 query, and return the matches
+// with the best ranked first.
+func (sdb *SQLDb) SearchFTS(table, query string, opts ...SearchOption) ([]FTSMatch, error) {
+	cfg := searchConfig{limit: -1, highlight: -1, start: "[", end: "]"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	snippet, highlight := "''", "''"
+	var args []interface{}
+	if cfg.snippet {
+		tokens := cfg.snippetTokens
+		if tokens <= 0 {
+			tokens = defaultSnippetTokens
+		}
+		snippet = fmt.Sprintf("snippet(%s, ?, ?, ?, '...', ?)", table)
+		args = append(args, cfg.snippetColumn, cfg.start, cfg.end, tokens)
+	}
+	if cfg.highlight >= 0 {
+		highlight = fmt.Sprintf("highlight(%s, ?, ?, ?)", table)
+		args = append(args, cfg.highlight, cfg.start, cfg.end)
+	}
+	stmt := fmt.Sprintf("SELECT rowid, rank, %s, %s FROM %s WHERE %s MATCH ? ORDER BY rank LIMIT ?",
+		snippet, highlight, table, table)
+	args = append(args, query, cfg.limit)
+
+	var matches []FTSMatch
+	err := sdb.MultiQueryArgs(stmt, args, func(rows *sql.Rows) (bool, error) {
+		var m FTSMatch
+		if err := rows.Scan(&m.RowID, &m.Rank, &m.Snippet, &m.Highlight); err != nil {
+			return false, err
+		}
+		matches = append(matches, m)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
@@ -0,0 +1,111 @@
+//go:build sqlite_fts5 && !minimal
+
+package sqldb
+
+import (
+	"testing"
+)
+
+func openFTSTestDb(t *testing.T) *SQLDb {
+	sdb := openTestDb(t)
+	if err := sdb.CreateTable("articles (id INTEGER PRIMARY KEY, title TEXT, body TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for _, article := range [][]interface{}{
+		{1, "Go databases", "Using SQLite from Go with database/sql."},
+		{2, "Gardening", "Growing tomatoes in a small garden."},
+	} {
+		if err := sdb.Exec("INSERT INTO articles (id, title, body) VALUES (?, ?, ?)", article...); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+	return sdb
+}
+
+func TestCreateFTSTable_Content(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openFTSTestDb(t)
+	defer closeDb(t, &sdb)
+
+	if err := sdb.CreateFTSTable("articles_fts", []string{"title", "body"},
+		FTSOptions{ContentTable: "articles", ContentRowID: "id", Tokenizer: "porter unicode61"}); err != nil {
+		t.Fatalf("CreateFTSTable error: %v", err)
+	}
+
+	// Existing rows are indexed.
+	matches, err := sdb.SearchFTS("articles_fts", "sqlite", WithSearchSnippet(1, 4), WithSearchHighlight(0))
+	if err != nil {
+		t.Fatalf("SearchFTS error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].RowID != 1 {
+		t.Fatalf("Expected article 1, but was %v", matches)
+	}
+	if matches[0].Snippet != "Using [SQLite] from Go..." {
+		t.Errorf("Unexpected snippet %q", matches[0].Snippet)
+	}
+	if matches[0].Highlight != "Go databases" {
+		t.Errorf("Unexpected highlight %q", matches[0].Highlight)
+	}
+
+	// The triggers keep the index in sync.
+	if err := sdb.Exec("INSERT INTO articles (id, title, body) VALUES (3, 'Go gardening', 'Tomatoes and Go.')"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if err := sdb.Exec("UPDATE articles SET body = 'Growing peppers.' WHERE id = 2"); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	matches, err = sdb.SearchFTS("articles_fts", "tomato", WithSearchHighlight(1), WithSearchMarkers("<b>", "</b>"))
+	if err != nil {
+		t.Fatalf("SearchFTS error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].RowID != 3 || matches[0].Highlight != "<b>Tomatoes</b> and Go." {
+		t.Errorf("Expected article 3, but was %v", matches)
+	}
+	if err := sdb.Exec("DELETE FROM articles WHERE id = 1"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	matches, err = sdb.SearchFTS("articles_fts", "go", WithSearchLimit(5))
+	if err != nil || len(matches) != 1 || matches[0].RowID != 3 {
+		t.Errorf("Expected only article 3, but was %v: %v", matches, err)
+	}
+
+	if err := sdb.DropFTSTable("articles_fts"); err != nil {
+		t.Fatalf("DropFTSTable error: %v", err)
+	}
+	if exists, err := sdb.TableExists("articles_fts"); err != nil || exists {
+		t.Errorf("Expected FTS table to be dropped: %v, %v", exists, err)
+	}
+	if err := sdb.Exec("INSERT INTO articles (id, title, body) VALUES (4, 'a', 'b')"); err != nil {
+		t.Errorf("Expected sync triggers to be dropped: %v", err)
+	}
+}
+
+func TestCreateFTSTable_Standalone(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	if err := sdb.CreateFTSTable("notes", []string{"text"}, FTSOptions{Prefix: "2"}); err != nil {
+		t.Fatalf("CreateFTSTable error: %v", err)
+	}
+	for _, text := range []string{"alpha beta", "beta beta gamma", "delta"} {
+		if err := sdb.Exec("INSERT INTO notes (text) VALUES (?)", text); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+	matches, err := sdb.SearchFTS("notes", "be*")
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, but was %v: %v", matches, err)
+	}
+	// More occurrences rank better.
+	if matches[0].RowID != 2 || matches[0].Rank > matches[1].Rank {
+		t.Errorf("Expected row 2 ranked first, but was %v", matches)
+	}
+	if _, err := sdb.SearchFTS("notes", "\"unterminated"); err == nil {
+		t.Error("Expected error for an invalid query")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !minimal
+
+package sqldb
+
+// Minimal reports whether the package was built with the minimal build tag.
+const Minimal = false
@@ -0,0 +1,74 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+)
+
+// The declarations the minimal build tag compiles out, by package directory.
+var minimalExcluded = map[string][]string{
+	".": {
+		"CreateFTSTable", "RebuildFTSTable", "DropFTSTable", "SearchFTS",
+		"ExportTable", "ImportTable", "ImportTableColumns", "Format",
+		"PublishExpvar", "PrometheusHandler",
+	},
+	"sqldbtest": {"LoadCSVFixture"},
+}
+
+func TestMinimalBuild_Excludes(t *testing.T) {
+	if Minimal {
+		t.Error("Expected Minimal to be unset without the minimal build tag")
+	}
+	for dir, names := range minimalExcluded {
+		for _, tags := range [][]string{nil, {"minimal"}} {
+			declared := testDeclaredNames(t, dir, tags)
+			for _, name := range names {
+				// Every excluded declaration is in the full build, and none is in the minimal build.
+				if declared[name] == (tags != nil) {
+					t.Errorf("Expected %s in %s to be declared only without the minimal tag, but was declared with tags %v: %v",
+						name, dir, tags, declared[name])
+				}
+			}
+		}
+	}
+}
+
+// testDeclaredNames returns the names of the top level functions, methods, and types of the
+// package in the directory, as built with the build tags.
+func testDeclaredNames(t *testing.T, dir string, tags []string) map[string]bool {
+	// The tests run in the test folder, under the package directory.
+	dir = filepath.Join("..", dir)
+	ctx := build.Default
+	ctx.BuildTags = tags
+	pkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		t.Fatalf("ImportDir error: %v", err)
+	}
+	declared := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			t.Fatalf("ParseFile error: %v", err)
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				declared[decl.Name.Name] = true
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						declared[ts.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return declared
+}
@@ -0,0 +1,36 @@
+package sqldb
+
+import (
+	"fmt"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// RegisterFunc - Make the Go function callable from SQL by the name, on every connection of
+// the database. The function's arguments and results must be types that convert to SQLite
+// values; it may also return an error as its last result. A pure function always returns the
+// same result for the same arguments, which lets SQLite use it in indexes and optimize calls.
+// See the go-sqlite3 SQLiteConn.RegisterFunc documentation for the details.
+func (sdb *SQLDb) RegisterFunc(name string, fn interface{}, pure bool) error {
+	err := sdb.conns.addSetup("func:"+name, func(conn *sqlite3.SQLiteConn) error {
+		return conn.RegisterFunc(name, fn, pure)
+	})
+	if err != nil {
+		return fmt.Errorf("dberror: registering function %s: %v", name, err)
+	}
+	return nil
+}
+
+// RegisterAggregator - Make the aggregate function callable from SQL by the name, on every
+// connection of the database. The impl is a constructor function returning a pointer to a type
+// with a Step method, which is called for each row, and a Done method returning the result.
+// See the go-sqlite3 SQLiteConn.RegisterAggregator documentation for the details.
+func (sdb *SQLDb) RegisterAggregator(name string, impl interface{}, pure bool) error {
+	err := sdb.conns.addSetup("aggregator:"+name, func(conn *sqlite3.SQLiteConn) error {
+		return conn.RegisterAggregator(name, impl, pure)
+	})
+	if err != nil {
+		return fmt.Errorf("dberror: registering aggregator %s: %v", name, err)
+	}
+	return nil
+}
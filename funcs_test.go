@@ -0,0 +1,80 @@
+package sqldb
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testProduct struct {
+	product int64
+}
+
+func (p *testProduct) Step(n int64) {
+	p.product *= n
+}
+
+func (p *testProduct) Done() int64 {
+	return p.product
+}
+
+func TestRegisterFunc(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	if err := sdb.RegisterFunc("regexp", func(pattern, s string) (bool, error) {
+		return regexp.MatchString(pattern, s)
+	}, true); err != nil {
+		t.Fatalf("RegisterFunc error: %v", err)
+	}
+	if err := sdb.RegisterAggregator("product", func() *testProduct {
+		return &testProduct{product: 1}
+	}, true); err != nil {
+		t.Fatalf("RegisterAggregator error: %v", err)
+	}
+	if err := sdb.RegisterFunc("bad", "not a function", true); err == nil {
+		t.Error("Expected error registering a non-function")
+	}
+
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for id, name := range []string{"apple", "banana", "avocado"} {
+		if err := sdb.Exec("INSERT INTO testtable (id, name) VALUES (?, ?)", id+2, name); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	// Functions must be available on every connection of the pool, including new ones.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var names []string
+			if err := sdb.Select("name").From("testtable").Where("name REGEXP ?", "^a").OrderBy("name").
+				Multi(func(rows *sql.Rows) (bool, error) {
+					var name string
+					err := rows.Scan(&name)
+					names = append(names, name)
+					return false, err
+				}); err != nil {
+				t.Errorf("REGEXP query error: %v", err)
+			}
+			if strings.Join(names, ",") != "apple,avocado" {
+				t.Errorf("Expected apple and avocado, but was %v", names)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var product int64
+	if err := sdb.SingleQuery("SELECT product(id) FROM testtable", &product); err != nil || product != 24 {
+		t.Errorf("Expected product 24, but was %v: %v", product, err)
+	}
+}
@@ -0,0 +1,83 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnhealthy is returned by a health check whose quick check found problems with the database.
+var ErrUnhealthy = errors.New("dberror: database failed its health check")
+
+// HealthOption - Configure a health check.
+type HealthOption func(cfg *healthConfig)
+
+type healthConfig struct {
+	quickCheck bool
+}
+
+// WithQuickCheck - Also run SQLite's quick check of the database structure, which takes
+// time proportional to the size of the database, so is best suited to infrequent checks.
+func WithQuickCheck() HealthOption {
+	return func(cfg *healthConfig) {
+		cfg.quickCheck = true
+	}
+}
+
+// HealthCheck - Verify that the database can be reached, for use in readiness probes.
+// Returns ErrShuttingDown once the database has begun shutting down, and an error wrapping
+// ErrUnhealthy if the optional quick check finds problems. The check is abandoned if ctx is done.
+func (sdb *SQLDb) HealthCheck(ctx context.Context, opts ...HealthOption) error {
+	var cfg healthConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if sdb.activity.isClosing() {
+		return ErrShuttingDown
+	}
+	// The check takes its own pooled connection with the context, rather than queuing behind
+	// the writer, so it is abandoned once ctx is done even if every connection is busy.
+	conn := sdb.conn
+	if conn == nil {
+		var err error
+		if conn, err = sdb.DB.Conn(ctx); err != nil {
+			return fmt.Errorf("dberror: could not communicate with database: %s: %v", sdb.filename, err)
+		}
+		defer conn.Close()
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("dberror: could not communicate with database: %s: %v", sdb.filename, err)
+	}
+	if !cfg.quickCheck {
+		return nil
+	}
+	return quickCheck(ctx, conn)
+}
+
+// quickCheck runs SQLite's quick check on the connection.
+func quickCheck(ctx context.Context, conn *sql.Conn) error {
+	rows, err := conn.QueryContext(ctx, "PRAGMA quick_check")
+	defer closeRows(rows)
+	if err != nil {
+		return fmt.Errorf("dberror: quick check: %v", err)
+	}
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return fmt.Errorf("dberror: quick check: %v", err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dberror: quick check: %v", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnhealthy, strings.Join(problems, "; "))
+	}
+	return nil
+}
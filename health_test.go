@@ -0,0 +1,90 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck error: %v", err)
+	}
+	if err := sdb.HealthCheck(context.Background(), WithQuickCheck()); err != nil {
+		t.Errorf("HealthCheck with quick check error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sdb.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck with a canceled context did not return an error")
+	}
+
+	sdb.activity.setClosing()
+	if err := sdb.HealthCheck(context.Background()); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, but was %v", err)
+	}
+}
+
+func TestHealthCheck_SingleWriter(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testDbName, WithSingleWriter(), WithMaxOpenConns(2))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	// Inside a transaction, the check runs on the transaction connection.
+	err = sdb.InTx(func(tx *SQLTx) error {
+		return tx.HealthCheck(context.Background(), WithQuickCheck())
+	})
+	if err != nil {
+		t.Errorf("HealthCheck in transaction error: %v", err)
+	}
+
+	// Hold a writer job open, so that anything queued behind it waits.
+	started, release := make(chan struct{}), make(chan struct{})
+	held := make(chan error, 1)
+	go func() {
+		held <- sdb.InTx(func(tx *SQLTx) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer func() {
+		close(release)
+		if err := <-held; err != nil {
+			t.Errorf("InTx error: %v", err)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sdb.HealthCheck(ctx, WithQuickCheck()); err != nil {
+		t.Errorf("HealthCheck with the writer busy error: %v", err)
+	}
+
+	// With the pool exhausted too, the check is abandoned once ctx is done.
+	conn, err := sdb.DB.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn error: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sdb.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck with the pool exhausted did not return an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected HealthCheck to return once ctx is done, but took %v", elapsed)
+	}
+}
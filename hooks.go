@@ -0,0 +1,90 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// QueryHook - Called after every statement is executed or queried through the SQLDb,
+// with the bound arguments, how long it took, and the error, if any. For queries, the
+// duration covers running the statement, but not iterating over its rows.
+// Hooks are called synchronously, so should return quickly.
+type QueryHook func(stmt string, args []interface{}, dur time.Duration, err error)
+
+// WithQueryHook - Call the hook after every statement.
+func WithQueryHook(hook QueryHook) Option {
+	return func(sdb *SQLDb) {
+		sdb.hooks.add(hook)
+	}
+}
+
+// OnQuery - Call the hook after every statement, in addition to any hooks already added.
+func (sdb *SQLDb) OnQuery(hook QueryHook) {
+	sdb.hooks.add(hook)
+}
+
+// LogQueries - Return a QueryHook that logs every failed statement, and every statement
+// that takes at least the slow duration, to the logger. A nil logger uses the standard logger.
+func LogQueries(logger *log.Logger, slow time.Duration) QueryHook {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(stmt string, args []interface{}, dur time.Duration, err error) {
+		switch {
+		case err != nil:
+			logger.Printf("dberror: %s %v failed after %v: %v", stmt, args, dur, err)
+		case dur >= slow:
+			logger.Printf("dbslow: %s %v took %v", stmt, args, dur)
+		}
+	}
+}
+
+type queryHooks struct {
+	mu    sync.RWMutex
+	hooks []QueryHook
+}
+
+func (qh *queryHooks) add(hook QueryHook) {
+	if hook == nil {
+		return
+	}
+	qh.mu.Lock()
+	defer qh.mu.Unlock()
+	qh.hooks = append(qh.hooks, hook)
+}
+
+func (qh *queryHooks) fire(stmt string, args []interface{}, dur time.Duration, err error) {
+	qh.mu.RLock()
+	hooks := qh.hooks
+	qh.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(stmt, args, dur, err)
+	}
+}
+
+// query runs the query with the bound arguments, and reports it to the query hooks.
+func (sdb *SQLDb) query(stmt string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	var rows *sql.Rows
+	err := sdb.retryPolicy(stmt).Do(func() (err error) {
+		rows, err = sdb.runner().QueryContext(context.Background(), stmt, args...)
+		return err
+	})
+	sdb.hooks.fire(stmt, args, time.Since(start), err)
+	if err == nil && sdb.scans != nil {
+		sdb.scans.queue(stmt, args)
+	}
+	return rows, err
+}
+
+// releaseRows closes the rows of a query run with query, and then explains any queries
+// queued by WarnOnFullScan.
+func (sdb *SQLDb) releaseRows(rows *sql.Rows) {
+	closeRows(rows)
+	if sdb.scans != nil {
+		sdb.scans.explain(sdb)
+	}
+}
@@ -0,0 +1,83 @@
+package sqldb
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testQueryEvent struct {
+	stmt string
+	args []interface{}
+	err  error
+}
+
+func TestQueryHooks(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	var events []testQueryEvent
+	sdb, err := OpenDb(testDbName, WithQueryHook(func(stmt string, args []interface{}, dur time.Duration, err error) {
+		if dur < 0 {
+			t.Errorf("Negative duration for %s", stmt)
+		}
+		events = append(events, testQueryEvent{stmt: stmt, args: args, err: err})
+	}))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	events = nil
+	extra := 0
+	sdb.OnQuery(func(stmt string, args []interface{}, dur time.Duration, err error) {
+		extra++
+	})
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (?)", 1); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	var id int
+	if err := sdb.SingleQuery("SELECT id FROM testtable", &id); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	sdb.Exec("INSERT INTO notatable (id) VALUES (1)")
+
+	if len(events) != 4 || extra != 4 {
+		t.Fatalf("Expected 4 events for each hook, but was %v and %v", len(events), extra)
+	}
+	if events[1].stmt != "INSERT INTO testtable (id) VALUES (?)" || len(events[1].args) != 1 || events[1].args[0] != 1 {
+		t.Errorf("Unexpected insert event: %v", events[1])
+	}
+	if events[2].stmt != "SELECT id FROM testtable" || events[2].err != nil {
+		t.Errorf("Unexpected query event: %v", events[2])
+	}
+	if events[3].err == nil {
+		t.Error("Expected failed statement event to have an error")
+	}
+}
+
+func TestLogQueries(t *testing.T) {
+	var buf bytes.Buffer
+	hook := LogQueries(log.New(&buf, "", 0), time.Second)
+	hook("SELECT 1", nil, time.Millisecond, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected fast query to not be logged, but was %s", buf.String())
+	}
+	hook("SELECT 2", nil, 2*time.Second, nil)
+	if !strings.Contains(buf.String(), "dbslow: SELECT 2") {
+		t.Errorf("Expected slow query to be logged, but was %s", buf.String())
+	}
+	buf.Reset()
+	hook("SELECT 3", nil, time.Millisecond, errTestHook)
+	if !strings.Contains(buf.String(), "dberror: SELECT 3") {
+		t.Errorf("Expected failed query to be logged, but was %s", buf.String())
+	}
+}
+
+var errTestHook = errors.New("hook failure")
@@ -0,0 +1,191 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxBindVariables is the lowest SQLITE_MAX_VARIABLE_NUMBER of any SQLite build
+// in common use, so statements stay within the limit on every platform.
+const maxBindVariables = 999
+
+// InsertMany - Insert the rows into the table columns inside a single save point.
+// Rows are batched into multi-value INSERT statements that respect SQLite's bind variable
+// limit. Either all rows are inserted, or none are. If any rows violate a constraint, or do not
+// have a value for each column, a *MultiError reporting the index of every failed row is
+// returned. Other errors, such as a missing table, are returned as they are. The timestamp
+// columns of the table's conventions are filled in, unless they are among the columns.
+func (sdb *SQLDb) InsertMany(table string, columns []string, rows [][]interface{}) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("dberror: no columns to insert into %s", table)
+	}
+	if stamps := stampColumns(table, columns); len(stamps) > 0 {
+		columns, rows = stampRows(columns, rows, stamps)
+	}
+	if len(columns) > maxBindVariables {
+		return fmt.Errorf("dberror: too many columns to insert into %s: %d", table, len(columns))
+	}
+	var me MultiError
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			me.add(i, fmt.Errorf("dberror: row has %d values, expected %d for %s", len(row), len(columns), table))
+		}
+	}
+	if err := me.errorOrNil(); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	batchSize := maxBindVariables / len(columns)
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		for start := 0; start < len(rows); start += batchSize {
+			end := min(start+batchSize, len(rows))
+			if err := tx.insertBatch(table, columns, rows[start:end]); err != nil {
+				if !isConstraint(err) {
+					// The error is not about the rows, such as a missing table.
+					return err
+				}
+				// Insert the rows of the failed batch one at a time to find which failed.
+				for i := start; i < end; i++ {
+					if err := tx.insertBatch(table, columns, rows[i:i+1]); err != nil {
+						me.add(i, err)
+					}
+				}
+			}
+		}
+		return me.errorOrNil()
+	})
+}
+
+// stampRows returns the columns and copies of the rows with the timestamp columns added.
+func stampRows(columns []string, rows [][]interface{}, stamps []string) ([]string, [][]interface{}) {
+	now := timeNow()
+	stamped := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		stamped[i] = append(make([]interface{}, 0, len(row)+len(stamps)), row...)
+		for range stamps {
+			stamped[i] = append(stamped[i], now)
+		}
+	}
+	return append(append([]string{}, columns...), stamps...), stamped
+}
+
+func (sdb *SQLDb) insertBatch(table string, columns []string, rows [][]interface{}) error {
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	values := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		values[i] = placeholders
+		args = append(args, row...)
+	}
+	return sdb.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		table, strings.Join(columns, ", "), strings.Join(values, ", ")), args...)
+}
+
+// Upsert - Insert the column values as a new row in the table, or update the existing row
+// when the insert conflicts on the key columns. The key columns must have a unique index
+// or be the primary key, and must be included in values. The timestamp columns of the table's
+// conventions are filled in, and the creation time of an existing row is kept.
+func (sdb *SQLDb) Upsert(table string, keyCols []string, values map[string]interface{}) error {
+	if len(keyCols) == 0 {
+		return fmt.Errorf("dberror: no key columns to upsert into %s", table)
+	}
+	isKey := make(map[string]bool, len(keyCols))
+	for _, col := range keyCols {
+		if _, ok := values[col]; !ok {
+			return fmt.Errorf("dberror: no value for key column %s to upsert into %s", col, table)
+		}
+		isKey[col] = true
+	}
+
+	conv := lookupConventions(table)
+	if stamps := conv.insertStamps(); len(stamps) > 0 {
+		stamped := make(map[string]interface{}, len(values)+len(stamps))
+		for col, value := range values {
+			stamped[col] = value
+		}
+		now := timeNow()
+		for _, stamp := range stamps {
+			if _, ok := stamped[stamp]; !ok {
+				stamped[stamp] = now
+			}
+		}
+		values = stamped
+	}
+
+	// Sort the columns so the same values always generate the same statement.
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	args := make([]interface{}, len(columns))
+	var updates []string
+	for i, col := range columns {
+		args[i] = values[col]
+		if !isKey[col] && !conv.isStamp(col) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", col, col))
+		}
+	}
+	// The creation time is kept, and the update time only changes along with other columns.
+	if len(updates) > 0 && conv.UpdatedAt != "" {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", conv.UpdatedAt, conv.UpdatedAt))
+	}
+	action := "NOTHING"
+	if len(updates) > 0 {
+		action = "UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return sdb.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO %s",
+		table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+		strings.Join(keyCols, ", "), action), args...)
+}
+
+// InsertReturningID - Execute the insert statement with the bound arguments, and return the
+// rowid of the inserted row. Returns an error wrapping ErrNoRows if no row was inserted, such as
+// by an INSERT OR IGNORE that was ignored.
+func (sdb *SQLDb) InsertReturningID(stmt string, args ...interface{}) (int64, error) {
+	res, err := sdb.ExecResults(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("dberror: could not retrieve the inserted row count for %s: %v", stmt, err)
+	}
+	if inserted == 0 {
+		// The last insert rowid is left over from an earlier insert.
+		return 0, fmt.Errorf("dberror: no row was inserted by %s: %w", stmt, ErrNoRows)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("dberror: could not retrieve the inserted row id for %s: %v", stmt, err)
+	}
+	return id, nil
+}
+
+// InsertReturning - Execute the insert statement with the bound arguments, and scan the value
+// of the column of the inserted row into dest, using a RETURNING clause. Unlike
+// InsertReturningID, it works for WITHOUT ROWID tables and keys that are not the rowid.
+// It runs like any other write, such as on the writer in single writer mode.
+// Returns an error wrapping ErrNoRows if no row was inserted.
+func (sdb *SQLDb) InsertReturning(dest interface{}, column string, stmt string, args ...interface{}) error {
+	stmt = fmt.Sprintf("%s RETURNING %s", stmt, column)
+	return sdb.write(stmt, args, func(runner sqlRunner, args []interface{}) (string, error) {
+		rows, err := runner.QueryContext(context.Background(), stmt, args...)
+		if err != nil {
+			return "executing", err
+		}
+		defer closeRows(rows)
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return "executing", err
+			}
+			return "executing", ErrNoRows
+		}
+		return "scanning", ScanRow(rows, dest)
+	})
+}
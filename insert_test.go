@@ -0,0 +1,208 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertMany(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	// Enough rows to require several batches.
+	const rowCount = 2500
+	rows := make([][]interface{}, rowCount)
+	for i := range rows {
+		rows[i] = []interface{}{i, "value"}
+	}
+	if err := sdb.InsertMany("testtable", []string{"id", "field1"}, rows); err != nil {
+		t.Fatalf("InsertMany error: %v", err)
+	}
+
+	var count, sum int
+	if err := sdb.SingleQuery("SELECT COUNT(*), SUM(id) FROM testtable", &count, &sum); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if count != rowCount {
+		t.Errorf("Expected %v rows, but was %v", rowCount, count)
+	}
+	if sum != rowCount*(rowCount-1)/2 {
+		t.Errorf("Expected id sum %v, but was %v", rowCount*(rowCount-1)/2, sum)
+	}
+}
+
+func TestInsertMany_RollbackOnError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	// The duplicate key lands in the second batch, after the first batch has been inserted.
+	rows := make([][]interface{}, 1500)
+	for i := range rows {
+		rows[i] = []interface{}{i}
+	}
+	rows[len(rows)-1] = []interface{}{0}
+	err := sdb.InsertMany("testtable", []string{"id"}, rows)
+	var me *MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("Expected MultiError, but was %v", err)
+	}
+	if !reflect.DeepEqual(me.Indexes(), []int{len(rows) - 1}) {
+		t.Errorf("Expected failed index %v, but was %v", len(rows)-1, me.Indexes())
+	}
+	exists, err := sdb.QueryExists("SELECT id FROM testtable")
+	if err != nil || exists {
+		t.Errorf("Expected all rows to be rolled back: %v, %v", exists, err)
+	}
+}
+
+func TestInsertMany_BadRow(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	err := sdb.InsertMany("testtable", []string{"id", "field1"}, [][]interface{}{{1, "a"}, {2}})
+	var me *MultiError
+	if !errors.As(err, &me) || !reflect.DeepEqual(me.Indexes(), []int{1}) {
+		t.Errorf("Expected MultiError for the short row, but was %v", err)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, field1 TEXT, field2 TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	err := sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"id": 1, "field1": "a", "field2": "b"})
+	if err != nil {
+		t.Fatalf("Upsert insert error: %v", err)
+	}
+	// Only the columns given are updated on conflict.
+	err = sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"id": 1, "field1": "c"})
+	if err != nil {
+		t.Fatalf("Upsert update error: %v", err)
+	}
+
+	var count int
+	var field1, field2 string
+	if err := sdb.SingleQuery("SELECT COUNT(*), field1, field2 FROM testtable", &count, &field1, &field2); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if count != 1 || field1 != "c" || field2 != "b" {
+		t.Errorf("Expected 1 row (c, b), but was %v (%v, %v)", count, field1, field2)
+	}
+
+	// A key-only upsert leaves the existing row alone.
+	if err := sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"id": 1}); err != nil {
+		t.Errorf("Upsert key only error: %v", err)
+	}
+	if err := sdb.Upsert("testtable", []string{"id"}, map[string]interface{}{"field1": "d"}); err == nil {
+		t.Error("Upsert did not return an error for a missing key value")
+	}
+}
+
+func TestInsertReturningID(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, field1 TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateTable("codes (code TEXT PRIMARY KEY, seq INTEGER) WITHOUT ROWID"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	for expected := int64(1); expected <= 2; expected++ {
+		id, err := sdb.InsertReturningID("INSERT INTO testtable (field1) VALUES (?)", "a")
+		if err != nil || id != expected {
+			t.Errorf("Expected id %v, but was %v: %v", expected, id, err)
+		}
+	}
+	if _, err := sdb.InsertReturningID("INSERT INTO notatable (field1) VALUES (?)", "a"); err == nil {
+		t.Error("InsertReturningID into a missing table did not return an error")
+	}
+
+	var code string
+	err := sdb.InsertReturning(&code, "code", "INSERT INTO codes (code, seq) VALUES (upper(?), ?)", "abc", 1)
+	if err != nil || code != "ABC" {
+		t.Errorf("Expected code ABC, but was %v: %v", code, err)
+	}
+	if count, err := sdb.Count("codes", ""); err != nil || count != 1 {
+		t.Errorf("Expected 1 row, but was %v: %v", count, err)
+	}
+
+	// An ignored insert does not report the rowid of an earlier insert.
+	if _, err := sdb.InsertReturningID("INSERT OR IGNORE INTO testtable (id, field1) VALUES (1, ?)", "b"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows for an ignored insert, but was %v", err)
+	}
+	err = sdb.InsertReturning(&code, "code", "INSERT OR IGNORE INTO codes (code, seq) VALUES (?, ?)", "ABC", 2)
+	if !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows for an ignored insert, but was %v", err)
+	}
+}
+
+func TestInsertReturning_ShuttingDown(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("codes (code TEXT PRIMARY KEY) WITHOUT ROWID"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	// Like any other write, it is refused once the database starts shutting down.
+	sdb.activity.setClosing()
+	var code string
+	if err := sdb.InsertReturning(&code, "code", "INSERT INTO codes (code) VALUES (?)", "abc"); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, but was %v", err)
+	}
+}
+
+func TestInsertMany_NotRowError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	var stmts int
+	sdb.OnQuery(func(stmt string, _ []interface{}, _ time.Duration, _ error) {
+		if strings.HasPrefix(stmt, "INSERT") {
+			stmts++
+		}
+	})
+	err := sdb.InsertMany("notatable", []string{"id"}, [][]interface{}{{1}, {2}, {3}})
+	if err == nil {
+		t.Fatal("InsertMany did not return an error for a missing table")
+	}
+	var me *MultiError
+	if errors.As(err, &me) {
+		t.Errorf("Expected the error of the missing table, but was %v", err)
+	}
+	if stmts != 1 {
+		t.Errorf("Expected the rows not to be inserted one at a time, but there were %d inserts", stmts)
+	}
+}
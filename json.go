@@ -0,0 +1,58 @@
+package sqldb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON - Bind a value as a JSON text argument, or scan a JSON column into the value
+// pointed to by V. A NULL column leaves the value unchanged.
+//
+//	err := sdb.Exec("UPDATE docs SET body = ? WHERE id = ?", sqldb.JSON{V: doc}, id)
+//	err = sdb.SingleQueryArgs("SELECT body FROM docs WHERE id = ?", []interface{}{id}, sqldb.JSON{V: &doc})
+//
+// Struct fields tagged with the json option, as in `db:"body,json"`, are handled the same way.
+type JSON struct {
+	V interface{}
+}
+
+// Value - Encode the value as JSON text.
+func (j JSON) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan - Decode the JSON text into the value pointed to by V.
+func (j JSON) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("cannot decode %T as JSON", src)
+	}
+	return json.Unmarshal(data, j.V)
+}
+
+// InsertJSON - Insert the value as JSON text in the column of a new row of the table.
+func (sdb *SQLDb) InsertJSON(table, column string, v interface{}) (sql.Result, error) {
+	return sdb.ExecResults(fmt.Sprintf("INSERT INTO %s (%s) VALUES (json(?))", table, column), JSON{V: v})
+}
+
+// QueryJSONField - Decode the part of a JSON column at the path, such as "$.address.city",
+// into the value pointed to by dest, from the first row of the table that matches the where
+// condition with its bound arguments. An empty where condition matches every row. Returns an
+// error wrapping ErrNoRows if no rows match. A path missing from the JSON leaves dest unchanged.
+func (sdb *SQLDb) QueryJSONField(dest interface{}, table, column, path, where string, args ...interface{}) error {
+	stmt := fmt.Sprintf("SELECT %s -> ? FROM %s%s", column, table, whereClause(where))
+	return sdb.SingleQueryArgs(stmt, append([]interface{}{path}, args...), JSON{V: dest})
+}
@@ -0,0 +1,92 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type testPerson struct {
+	ID      int
+	Name    string
+	Address testAddress `db:"address,json"`
+	Tags    []string    `db:"tags,json"`
+}
+
+func TestJSON(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("docs (id INTEGER PRIMARY KEY, body TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	doc := map[string]interface{}{"name": "a", "address": testAddress{City: "Oslo", Zip: "0150"}}
+	res, err := sdb.InsertJSON("docs", "body", doc)
+	if err != nil {
+		t.Fatalf("InsertJSON error: %v", err)
+	}
+	id, _ := res.LastInsertId()
+
+	var city string
+	if err := sdb.QueryJSONField(&city, "docs", "body", "$.address.city", "id = ?", id); err != nil || city != "Oslo" {
+		t.Errorf("Expected Oslo, but was %s: %v", city, err)
+	}
+	var address testAddress
+	if err := sdb.QueryJSONField(&address, "docs", "body", "$.address", ""); err != nil || address.Zip != "0150" {
+		t.Errorf("Expected zip 0150, but was %v: %v", address, err)
+	}
+	missing := "unchanged"
+	if err := sdb.QueryJSONField(&missing, "docs", "body", "$.missing", ""); err != nil || missing != "unchanged" {
+		t.Errorf("Expected missing path to leave dest unchanged, but was %s: %v", missing, err)
+	}
+	if err := sdb.QueryJSONField(&city, "docs", "body", "$.name", "id = ?", id+1); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+
+	var body map[string]interface{}
+	if err := sdb.SingleQueryArgs("SELECT body FROM docs WHERE id = ?", []interface{}{id}, JSON{V: &body}); err != nil {
+		t.Fatalf("SingleQueryArgs error: %v", err)
+	}
+	if body["name"] != "a" {
+		t.Errorf("Expected name a, but was %v", body)
+	}
+	if _, err := sdb.InsertJSON("docs", "body", func() {}); err == nil {
+		t.Error("Expected error encoding a function")
+	}
+}
+
+func TestJSON_StructFields(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("people (id INTEGER, name TEXT, address TEXT, tags TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	person := testPerson{ID: 1, Name: "a", Address: testAddress{City: "Oslo"}, Tags: []string{"x", "y"}}
+	if _, err := sdb.InsertStruct("people", person); err != nil {
+		t.Fatalf("InsertStruct error: %v", err)
+	}
+	var city string
+	if err := sdb.SingleQuery("SELECT address ->> '$.city' FROM people", &city); err != nil || city != "Oslo" {
+		t.Errorf("Expected JSON address with city Oslo, but was %s: %v", city, err)
+	}
+
+	var actual testPerson
+	if err := sdb.QueryStruct(&actual, "SELECT id, name, address, tags FROM people"); err != nil {
+		t.Fatalf("QueryStruct error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, person) {
+		t.Errorf("Expected %v, but was %v", person, actual)
+	}
+}
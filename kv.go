@@ -0,0 +1,73 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The internal table that backs the key-value store.
+const kvTableName = internalPrefix + "kv"
+
+// KV - A durable key-value store for settings and other small values, kept in an internal
+// table of the database, which PatchDb creates. Values are stored as JSON.
+type KV struct {
+	sdb *SQLDb
+}
+
+// KV - Return the key-value store of the database.
+func (sdb *SQLDb) KV() *KV {
+	return &KV{sdb: sdb}
+}
+
+// Set - Store the value under the key, replacing any existing value.
+// The value may be anything that can be encoded as JSON.
+func (kv *KV) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("dberror: encoding value of %s: %v", key, err)
+	}
+	return kv.sdb.Upsert(kvTableName, []string{"key"}, map[string]interface{}{
+		"key":   key,
+		"value": string(data),
+	})
+}
+
+// Get - Decode the value stored under the key into the value pointed to by dest.
+// Returns an error wrapping ErrNoRows if the key is not set.
+func (kv *KV) Get(key string, dest interface{}) error {
+	var data string
+	if err := kv.sdb.SingleQueryArgs(fmt.Sprintf("SELECT value FROM %s WHERE key = ?", kvTableName),
+		[]interface{}{key}, &data); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		return fmt.Errorf("dberror: decoding value of %s: %v", key, err)
+	}
+	return nil
+}
+
+// GetInt - Return the integer stored under the key.
+func (kv *KV) GetInt(key string) (int, error) {
+	var value int
+	err := kv.Get(key, &value)
+	return value, err
+}
+
+// GetString - Return the string stored under the key.
+func (kv *KV) GetString(key string) (string, error) {
+	var value string
+	err := kv.Get(key, &value)
+	return value, err
+}
+
+// Delete - Remove the key and its value. Deleting a key that is not set is not an error.
+func (kv *KV) Delete(key string) error {
+	return kv.sdb.Exec(fmt.Sprintf("DELETE FROM %s WHERE key = ?", kvTableName), key)
+}
+
+// List - Return the keys that start with the prefix, in sorted order.
+func (kv *KV) List(prefix string) ([]string, error) {
+	return kv.sdb.queryNames(fmt.Sprintf("SELECT key FROM %s WHERE substr(key, 1, ?) = ? ORDER BY key", kvTableName),
+		utf8.RuneCountInString(prefix), prefix)
+}
@@ -0,0 +1,96 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKV(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	kv := sdb.KV()
+
+	type window struct {
+		Width  int
+		Height int
+	}
+	if err := kv.Set("ui.window", window{Width: 800, Height: 600}); err != nil {
+		t.Errorf("Set error: %v", err)
+	}
+	if err := kv.Set("ui.theme", "dark"); err != nil {
+		t.Errorf("Set error: %v", err)
+	}
+	if err := kv.Set("retries", 3); err != nil {
+		t.Errorf("Set error: %v", err)
+	}
+	if err := kv.Set("retries", 5); err != nil {
+		t.Errorf("Set replace error: %v", err)
+	}
+
+	var w window
+	if err := kv.Get("ui.window", &w); err != nil || w.Width != 800 || w.Height != 600 {
+		t.Errorf("Expected window 800x600, but was %v: %v", w, err)
+	}
+	if theme, err := kv.GetString("ui.theme"); err != nil || theme != "dark" {
+		t.Errorf("Expected dark, but was %s: %v", theme, err)
+	}
+	if retries, err := kv.GetInt("retries"); err != nil || retries != 5 {
+		t.Errorf("Expected 5, but was %v: %v", retries, err)
+	}
+	if _, err := kv.GetInt("ui.theme"); err == nil {
+		t.Error("Expected error decoding a string as an int")
+	}
+
+	keys, err := kv.List("ui.")
+	if err != nil || !reflect.DeepEqual(keys, []string{"ui.theme", "ui.window"}) {
+		t.Errorf("Expected ui keys, but was %v: %v", keys, err)
+	}
+	if keys, err = kv.List(""); err != nil || len(keys) != 3 {
+		t.Errorf("Expected 3 keys, but was %v: %v", keys, err)
+	}
+
+	if err := kv.Delete("retries"); err != nil {
+		t.Errorf("Delete error: %v", err)
+	}
+	if _, err := kv.GetInt("retries"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+	if err := kv.Delete("retries"); err != nil {
+		t.Errorf("Delete missing key error: %v", err)
+	}
+}
+
+func TestKV_ReservedTable(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	// The store's table has a reserved name, so an application table may be named kvstore.
+	if err := sdb.CreateTable("kvstore (key TEXT, value TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	kv := sdb.KV()
+
+	var value string
+	if err := kv.Get("name", &value); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows before a value is set, but was %v", err)
+	}
+	if keys, err := kv.List(""); err != nil || len(keys) != 0 {
+		t.Errorf("Expected no keys before a value is set, but was %v: %v", keys, err)
+	}
+	if err := kv.Set("name", "value"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+	if value, err := kv.GetString("name"); err != nil || value != "value" {
+		t.Errorf("Expected value, but was %v: %v", value, err)
+	}
+	expected := []string{"kvstore"}
+	if tables, err := sdb.ListTables(); err != nil || !reflect.DeepEqual(tables, expected) {
+		t.Errorf("Expected tables %v, but was %v: %v", expected, tables, err)
+	}
+}
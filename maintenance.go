@@ -0,0 +1,50 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Vacuum - Rebuild the database file, repacking it into the minimum amount of disk space.
+func (sdb *SQLDb) Vacuum() error {
+	return sdb.Exec("VACUUM")
+}
+
+// VacuumInto - Write a vacuumed copy of the database to a new file at path.
+// The copy is consistent even while other connections are writing. It works on read-only
+// databases, since only the copy is written.
+func (sdb *SQLDb) VacuumInto(path string) error {
+	return sdb.Exec("VACUUM INTO ?", path)
+}
+
+// CloneTo - Write a consistent copy of the database to a new file at path, and return an open
+// handle to the copy, such as for a snapshot to attach to a bug report, or an isolated copy
+// of a test database. The copy is opened with opts, not with the options of the database.
+func (sdb *SQLDb) CloneTo(path string, opts ...Option) (*SQLDb, error) {
+	if err := sdb.VacuumInto(path); err != nil {
+		return nil, fmt.Errorf("dberror: could not clone %s to %s: %v", sdb.filename, path, err)
+	}
+	return OpenDb(path, opts...)
+}
+
+// Analyze - Gather statistics about tables and indexes for the query planner.
+func (sdb *SQLDb) Analyze() error {
+	return sdb.Exec("ANALYZE")
+}
+
+// IntegrityCheck - Run a full integrity check of the database, and return the problems found.
+// An empty result means the database passed.
+func (sdb *SQLDb) IntegrityCheck() ([]string, error) {
+	var problems []string
+	err := sdb.MultiQuery("PRAGMA integrity_check", func(rows *sql.Rows) error {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return err
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+		return nil
+	})
+	return problems, err
+}
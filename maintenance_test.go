@@ -0,0 +1,136 @@
+package sqldb
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+const testVacuumDbName = "TestVacuumDb"
+
+func TestVacuumAndAnalyze(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("test_idx ON testtable (id)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+	if err := sdb.Analyze(); err != nil {
+		t.Errorf("Analyze error: %v", err)
+	}
+	exists, err := sdb.QueryExists("SELECT name FROM sqlite_master WHERE name = 'sqlite_stat1'")
+	if err != nil || !exists {
+		t.Errorf("Expected Analyze to create statistics: %v, %v", exists, err)
+	}
+	if err := sdb.Vacuum(); err != nil {
+		t.Errorf("Vacuum error: %v", err)
+	}
+}
+
+func TestVacuumInto(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testVacuumDbName)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.VacuumInto(testVacuumDbName); err != nil {
+		t.Fatalf("VacuumInto error: %v", err)
+	}
+
+	vacuumed, err := OpenDb(testVacuumDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &vacuumed)
+	exists, err := vacuumed.QueryExists("SELECT patchid FROM version")
+	if err != nil || !exists {
+		t.Errorf("Expected version table in vacuumed copy: %v, %v", exists, err)
+	}
+}
+
+func TestCloneTo(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testVacuumDbName)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1), (2)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	clone, err := sdb.CloneTo(testVacuumDbName)
+	if err != nil {
+		t.Fatalf("CloneTo error: %v", err)
+	}
+	defer closeDb(t, &clone)
+	if err := clone.Exec("DELETE FROM testtable"); err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", ""); err != nil || count != 2 {
+		t.Errorf("Expected the original to keep 2 rows, but was %v: %v", count, err)
+	}
+
+	// The clone cannot overwrite an existing database.
+	if _, err := sdb.CloneTo(testVacuumDbName); err == nil {
+		t.Error("CloneTo an existing database did not return an error")
+	}
+}
+
+func TestCloneTo_ReadOnly(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testVacuumDbName)
+
+	sdb := openPatchedTestDb(t)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1), (2)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	closeDb(t, &sdb)
+
+	rdb, err := OpenDbReadOnly(testDbName)
+	if err != nil {
+		t.Fatalf("OpenDbReadOnly error: %v", err)
+	}
+	defer closeDb(t, &rdb)
+	// Cloning only writes the copy, so a read-only database can be cloned.
+	clone, err := rdb.CloneTo(testVacuumDbName)
+	if err != nil {
+		t.Fatalf("CloneTo error: %v", err)
+	}
+	defer closeDb(t, &clone)
+	if count, err := clone.Count("testtable", ""); err != nil || count != 2 {
+		t.Errorf("Expected the clone to have 2 rows, but was %v: %v", count, err)
+	}
+	// Other writes are still refused.
+	if err := rdb.Vacuum(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, but was %v", err)
+	}
+}
+
+func TestIntegrityCheck(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	problems, err := sdb.IntegrityCheck()
+	if err != nil {
+		t.Fatalf("IntegrityCheck error: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected no integrity problems, but was %v", problems)
+	}
+}
@@ -0,0 +1,320 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The struct tag used to override the column name of a field. A tag of "-" skips the field.
+// A "json" option, as in `db:"name,json"`, stores the field in the column as JSON text.
+const columnTagName = "db"
+
+// NameMapper - Map a Go struct field name to a database column name.
+type NameMapper func(fieldName string) string
+
+// SnakeCase - The default NameMapper. Maps Go field names such as UserID and HTTPStatus
+// to user_id and http_status.
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			// Start a new word after a lowercase letter or digit, or at the last
+			// capital of an acronym that is followed by a lowercase letter.
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// WithNameMapper - Use the name mapper to derive column names from struct field names.
+// Fields tagged with `db:"name"` always use the tagged name.
+func WithNameMapper(mapper NameMapper) Option {
+	return func(sdb *SQLDb) {
+		sdb.mapper = newStructMapper(mapper)
+	}
+}
+
+// structField is a mapped struct field, indexed for reflect.Value.FieldByIndex.
+type structField struct {
+	column string
+	index  []int
+	json   bool
+}
+
+type structMapper struct {
+	names  NameMapper
+	fields sync.Map // reflect.Type -> []structField
+}
+
+func newStructMapper(names NameMapper) *structMapper {
+	if names == nil {
+		names = SnakeCase
+	}
+	return &structMapper{names: names}
+}
+
+// ColumnName - Return the column name mapped to the struct field.
+func (sdb *SQLDb) ColumnName(field reflect.StructField) string {
+	return sdb.mapper.columnName(field)
+}
+
+// ColumnNames - Return the column names mapped to the fields of the struct, in field order.
+func (sdb *SQLDb) ColumnNames(v interface{}) ([]string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dberror: %T is not a struct", v)
+	}
+	fields := sdb.mapper.structFields(t)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns, nil
+}
+
+// QueryStructs - Query the database with the bound arguments, and append a struct for each
+// returned row to the slice pointed to by dest. The slice may hold structs or struct pointers.
+func (sdb *SQLDb) QueryStructs(dest interface{}, stmt string, args ...interface{}) (err error) {
+	defer sdb.recoverPanic(&err)
+	slice, err := newStructSlice(dest)
+	if err != nil {
+		return err
+	}
+
+	if args, err = convertArgs(args); err != nil {
+		return err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	for rows.Next() {
+		if err := slice.append(sdb.mapper, rows); err != nil {
+			return fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return nil
+}
+
+// QueryStruct - Query the database with the bound arguments, and scan the first returned row
+// into the struct pointed to by dest. Returns an error wrapping ErrNoRows if no rows match.
+func (sdb *SQLDb) QueryStruct(dest interface{}, stmt string, args ...interface{}) (err error) {
+	defer sdb.recoverPanic(&err)
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dberror: %T is not a pointer to a struct", dest)
+	}
+
+	if args, err = convertArgs(args); err != nil {
+		return err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	if rows.Next() {
+		if err := sdb.mapper.scanStruct(rows, v.Elem()); err != nil {
+			return fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+		}
+		return nil
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return fmt.Errorf("dberror: could not retrieve query value for %s: %w", stmt, ErrNoRows)
+}
+
+// InsertStruct - Insert the mapped fields of the struct as a new row in the table.
+// The timestamp columns of the table's conventions are filled in, unless mapped to a field.
+func (sdb *SQLDb) InsertStruct(table string, v interface{}) (sql.Result, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dberror: %T is not a struct", v)
+	}
+	fields := sdb.mapper.structFields(rv.Type())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("dberror: %T has no mapped fields", v)
+	}
+	columns := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+		args[i] = f.bindValue(rv)
+	}
+	for _, stamp := range stampColumns(table, columns) {
+		columns = append(columns, stamp)
+		args = append(args, timeNow())
+	}
+	return sdb.ExecResults(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")), args...)
+}
+
+func (sm *structMapper) columnName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup(columnTagName); ok && tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+	return sm.names(field.Name)
+}
+
+// structFields returns the mapped fields of the struct type, including the fields
+// of embedded structs. Unexported fields and fields tagged "-" are skipped.
+func (sm *structMapper) structFields(t reflect.Type) []structField {
+	if cached, ok := sm.fields.Load(t); ok {
+		return cached.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := sm.columnName(f)
+		if column == "-" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if _, tagged := f.Tag.Lookup(columnTagName); !tagged {
+				for _, ef := range sm.structFields(f.Type) {
+					ef.index = append([]int{i}, ef.index...)
+					fields = append(fields, ef)
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		fields = append(fields, structField{column: column, index: []int{i}, json: hasTagOption(f, "json")})
+	}
+	sm.fields.Store(t, fields)
+	return fields
+}
+
+// scanStruct scans the current row into the fields of the struct value.
+func (sm *structMapper) scanStruct(rows *sql.Rows, v reflect.Value) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields := sm.structFields(v.Type())
+	dest := make([]interface{}, len(columns))
+	for i, column := range columns {
+		for _, f := range fields {
+			if strings.EqualFold(f.column, column) {
+				dest[i] = f.scanDest(v)
+				break
+			}
+		}
+		if dest[i] == nil {
+			return fmt.Errorf("no field of %s is mapped to column %s", v.Type(), column)
+		}
+	}
+	return rows.Scan(dest...)
+}
+
+// structSlice is a slice of structs or struct pointers that rows are scanned into.
+type structSlice struct {
+	slice    reflect.Value
+	elemType reflect.Type
+	isPtr    bool
+}
+
+func newStructSlice(dest interface{}) (*structSlice, error) {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("dberror: %T is not a pointer to a slice", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dberror: %T is not a pointer to a slice of structs", dest)
+	}
+	return &structSlice{slice: slice, elemType: elemType, isPtr: isPtr}, nil
+}
+
+// append scans the current row into a new struct at the end of the slice.
+func (ss *structSlice) append(sm *structMapper, rows *sql.Rows) error {
+	elem := reflect.New(ss.elemType)
+	if err := sm.scanStruct(rows, elem.Elem()); err != nil {
+		return err
+	}
+	if !ss.isPtr {
+		elem = elem.Elem()
+	}
+	ss.slice.Set(reflect.Append(ss.slice, elem))
+	return nil
+}
+
+// hasTagOption reports whether the column tag of the field has the option.
+func hasTagOption(field reflect.StructField, option string) bool {
+	tag, ok := field.Tag.Lookup(columnTagName)
+	if !ok {
+		return false
+	}
+	for _, opt := range strings.Split(tag, ",")[1:] {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// bindValue returns the statement argument for the field of the struct value.
+func (f structField) bindValue(sv reflect.Value) interface{} {
+	v := sv.FieldByIndex(f.index)
+	if f.json {
+		return JSON{V: v.Interface()}
+	}
+	return bindValue(v)
+}
+
+// scanDest returns the scan destination for the field of the struct value.
+func (f structField) scanDest(sv reflect.Value) interface{} {
+	v := sv.FieldByIndex(f.index)
+	if f.json {
+		return JSON{V: v.Addr().Interface()}
+	}
+	return scanDest(v)
+}
+
+// bindValue returns the statement argument for the struct field value.
+func bindValue(v reflect.Value) interface{} {
+	// Registered conversions are applied to the bound arguments.
+	if _, ok := lookupConverter(v.Type()); ok {
+		return v.Interface()
+	}
+	if isUUIDType(v.Type()) {
+		return uuidBlob(v)
+	}
+	return v.Interface()
+}
+
+// scanDest returns the scan destination for the struct field value.
+func scanDest(v reflect.Value) interface{} {
+	if scanner, ok := converterScanner(v); ok {
+		return scanner
+	}
+	if isUUIDType(v.Type()) {
+		return uuidScanner{v: v}
+	}
+	return v.Addr().Interface()
+}
@@ -0,0 +1,130 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAudit struct {
+	CreatedBy string
+}
+
+type testRecord struct {
+	ID       int
+	UserName string
+	HTTPCode int    `db:"status"`
+	Ignored  string `db:"-"`
+	testAudit
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"UserName":   "user_name",
+		"HTTPStatus": "http_status",
+		"Field1":     "field1",
+		"already":    "already",
+	}
+	for name, expected := range tests {
+		if actual := SnakeCase(name); actual != expected {
+			t.Errorf("SnakeCase(%s): expected %s, but was %s", name, expected, actual)
+		}
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+
+	columns, err := sdb.ColumnNames(&testRecord{})
+	if err != nil {
+		t.Fatalf("ColumnNames error: %v", err)
+	}
+	expected := []string{"id", "user_name", "status", "created_by"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns %v, but was %v", expected, columns)
+	}
+	if _, err := sdb.ColumnNames(1); err == nil {
+		t.Error("ColumnNames did not return an error for a non-struct")
+	}
+}
+
+func TestWithNameMapper(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testDbName, WithNameMapper(strings.ToUpper))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	columns, err := sdb.ColumnNames(testRecord{})
+	if err != nil {
+		t.Fatalf("ColumnNames error: %v", err)
+	}
+	expected := []string{"ID", "USERNAME", "status", "CREATEDBY"}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("Expected columns %v, but was %v", expected, columns)
+	}
+}
+
+func TestInsertAndQueryStructs(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, user_name TEXT, status INTEGER, created_by TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	records := []testRecord{
+		{ID: 1, UserName: "alice", HTTPCode: 200, testAudit: testAudit{CreatedBy: "admin"}},
+		{ID: 2, UserName: "bob", HTTPCode: 404, testAudit: testAudit{CreatedBy: "admin"}},
+	}
+	for _, r := range records {
+		if _, err := sdb.InsertStruct("testtable", &r); err != nil {
+			t.Fatalf("InsertStruct error: %v", err)
+		}
+	}
+
+	var actual []testRecord
+	if err := sdb.QueryStructs(&actual, "SELECT * FROM testtable ORDER BY id"); err != nil {
+		t.Fatalf("QueryStructs error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, records) {
+		t.Errorf("Expected %v, but was %v", records, actual)
+	}
+
+	var ptrs []*testRecord
+	if err := sdb.QueryStructs(&ptrs, "SELECT id, user_name FROM testtable WHERE id = ?", 2); err != nil {
+		t.Fatalf("QueryStructs error: %v", err)
+	}
+	if len(ptrs) != 1 || ptrs[0].UserName != "bob" {
+		t.Errorf("Expected bob, but was %v", ptrs)
+	}
+
+	var single testRecord
+	if err := sdb.QueryStruct(&single, "SELECT id, status FROM testtable WHERE id = ?", 1); err != nil {
+		t.Fatalf("QueryStruct error: %v", err)
+	}
+	if single.HTTPCode != 200 {
+		t.Errorf("Expected status 200, but was %v", single.HTTPCode)
+	}
+	err := sdb.QueryStruct(&single, "SELECT id FROM testtable WHERE id = ?", 3)
+	if !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+
+	err = sdb.QueryStructs(&actual, "SELECT id, 1 AS unknown FROM testtable")
+	if err == nil {
+		t.Error("QueryStructs did not return an error for an unmapped column")
+	}
+}
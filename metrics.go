@@ -0,0 +1,124 @@
+package sqldb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics - Receive counts of database activity, for export to a monitoring system.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Query is called after every statement is executed or queried.
+	Query(dur time.Duration, err error)
+	// Transaction is called when a transaction begins.
+	Transaction()
+	// Rollback is called when a transaction or save point is rolled back.
+	Rollback()
+	// Patch is called after each patch function is applied, or fails.
+	Patch(patchID int, dur time.Duration, err error)
+	// GkeyAllocated is called when GetGkey allocates a gkey.
+	GkeyAllocated()
+}
+
+// WithMetrics - Report database activity to the metrics. Nil metrics report nothing.
+func WithMetrics(m Metrics) Option {
+	return func(sdb *SQLDb) {
+		if m == nil {
+			sdb.metrics = nopMetrics{}
+			return
+		}
+		sdb.metrics = m
+		sdb.hooks.add(func(_ string, _ []interface{}, dur time.Duration, err error) {
+			m.Query(dur, err)
+		})
+	}
+}
+
+// nopMetrics discards all activity, when no metrics are configured.
+type nopMetrics struct{}
+
+func (nopMetrics) Query(time.Duration, error)      {}
+func (nopMetrics) Transaction()                    {}
+func (nopMetrics) Rollback()                       {}
+func (nopMetrics) Patch(int, time.Duration, error) {}
+func (nopMetrics) GkeyAllocated()                  {}
+
+// Counters - Metrics that keep running totals of database activity.
+// A single Counters may be shared by several databases.
+type Counters struct {
+	queries       atomic.Int64
+	queryErrors   atomic.Int64
+	queryNanos    atomic.Int64
+	transactions  atomic.Int64
+	rollbacks     atomic.Int64
+	patches       atomic.Int64
+	patchErrors   atomic.Int64
+	patchNanos    atomic.Int64
+	gkeysAssigned atomic.Int64
+}
+
+// CounterSnapshot - The totals of a Counters at a point in time.
+type CounterSnapshot struct {
+	Queries       int64
+	QueryErrors   int64
+	QueryTime     time.Duration
+	Transactions  int64
+	Rollbacks     int64
+	Patches       int64
+	PatchErrors   int64
+	PatchTime     time.Duration
+	GkeysAssigned int64
+}
+
+// NewCounters - Create Counters starting from zero.
+func NewCounters() *Counters {
+	return &Counters{}
+}
+
+// Query - Count a statement.
+func (c *Counters) Query(dur time.Duration, err error) {
+	c.queries.Add(1)
+	c.queryNanos.Add(int64(dur))
+	if err != nil {
+		c.queryErrors.Add(1)
+	}
+}
+
+// Transaction - Count a transaction.
+func (c *Counters) Transaction() {
+	c.transactions.Add(1)
+}
+
+// Rollback - Count a rollback.
+func (c *Counters) Rollback() {
+	c.rollbacks.Add(1)
+}
+
+// Patch - Count a patch.
+func (c *Counters) Patch(_ int, dur time.Duration, err error) {
+	c.patches.Add(1)
+	c.patchNanos.Add(int64(dur))
+	if err != nil {
+		c.patchErrors.Add(1)
+	}
+}
+
+// GkeyAllocated - Count a gkey allocation.
+func (c *Counters) GkeyAllocated() {
+	c.gkeysAssigned.Add(1)
+}
+
+// Snapshot - Return the current totals.
+func (c *Counters) Snapshot() CounterSnapshot {
+	return CounterSnapshot{
+		Queries:       c.queries.Load(),
+		QueryErrors:   c.queryErrors.Load(),
+		QueryTime:     time.Duration(c.queryNanos.Load()),
+		Transactions:  c.transactions.Load(),
+		Rollbacks:     c.rollbacks.Load(),
+		Patches:       c.patches.Load(),
+		PatchErrors:   c.patchErrors.Load(),
+		PatchTime:     time.Duration(c.patchNanos.Load()),
+		GkeysAssigned: c.gkeysAssigned.Load(),
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+)
+
+// PublishExpvar - Publish the counters as an expvar map with the given name, so they are
+// served from /debug/vars. Like expvar.Publish, it panics if the name is already in use.
+func PublishExpvar(name string, c *Counters) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		s := c.Snapshot()
+		return map[string]interface{}{
+			"queries":         s.Queries,
+			"query_errors":    s.QueryErrors,
+			"query_seconds":   s.QueryTime.Seconds(),
+			"transactions":    s.Transactions,
+			"rollbacks":       s.Rollbacks,
+			"patches":         s.Patches,
+			"patch_errors":    s.PatchErrors,
+			"patch_seconds":   s.PatchTime.Seconds(),
+			"gkeys_allocated": s.GkeysAssigned,
+		}
+	}))
+}
+
+// PrometheusHandler - Return an http.Handler that serves the counters in the Prometheus
+// text exposition format. Metric names are prefixed with the namespace, or "sqldb" if empty.
+func PrometheusHandler(namespace string, c *Counters) http.Handler {
+	if namespace == "" {
+		namespace = "sqldb"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := c.Snapshot()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metric := func(name, kind, help string, value interface{}) {
+			fmt.Fprintf(w, "# HELP %s_%s %s\n# TYPE %s_%s %s\n%s_%s %v\n",
+				namespace, name, help, namespace, name, kind, namespace, name, value)
+		}
+		metric("queries_total", "counter", "Statements executed or queried.", s.Queries)
+		metric("query_errors_total", "counter", "Statements that failed.", s.QueryErrors)
+		metric("query_seconds_total", "counter", "Time spent running statements.", s.QueryTime.Seconds())
+		metric("transactions_total", "counter", "Transactions begun.", s.Transactions)
+		metric("rollbacks_total", "counter", "Transactions and save points rolled back.", s.Rollbacks)
+		metric("patches_total", "counter", "Patch functions applied.", s.Patches)
+		metric("patch_errors_total", "counter", "Patch functions that failed.", s.PatchErrors)
+		metric("patch_seconds_total", "counter", "Time spent applying patch functions.", s.PatchTime.Seconds())
+		metric("gkeys_allocated_total", "counter", "Gkeys allocated.", s.GkeysAssigned)
+	})
+}
@@ -0,0 +1,45 @@
+//go:build !minimal
+
+package sqldb
+
+import (
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	counters := NewCounters()
+	counters.Query(0, nil)
+	counters.Query(0, nil)
+	counters.Rollback()
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler("", counters).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE sqldb_queries_total counter\n",
+		"\nsqldb_queries_total 2\n",
+		"\nsqldb_rollbacks_total 1\n",
+		"\nsqldb_gkeys_allocated_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics, but was:\n%s", want, body)
+		}
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	counters := NewCounters()
+	counters.GkeyAllocated()
+	PublishExpvar("sqldb_test", counters)
+
+	v := expvar.Get("sqldb_test")
+	if v == nil {
+		t.Fatal("Expected published expvar")
+	}
+	if s := v.String(); !strings.Contains(s, `"gkeys_allocated":1`) {
+		t.Errorf("Expected gkeys_allocated of 1, but was %s", s)
+	}
+}
@@ -0,0 +1,94 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	counters := NewCounters()
+	patchFuncs := []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+	}
+	sdb, err := OpenAndPatchDb(testDbName, patchFuncs, WithMetrics(counters))
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	s := counters.Snapshot()
+	// The three internal patches and the test patch.
+	if s.Patches != 4 || s.PatchErrors != 0 {
+		t.Errorf("Expected 4 patches and no errors, but was %v and %v", s.Patches, s.PatchErrors)
+	}
+	if s.Queries == 0 || s.QueryTime <= 0 {
+		t.Errorf("Expected queries to be timed, but was %v in %v", s.Queries, s.QueryTime)
+	}
+
+	before := counters.Snapshot()
+	if _, err := sdb.GetGkey(); err != nil {
+		t.Errorf("GetGkey error: %v", err)
+	}
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	if err := sdb.RollbackTrans(); err != nil {
+		t.Errorf("RollbackTrans error: %v", err)
+	}
+	sdb.Exec("INSERT INTO notatable (id) VALUES (1)")
+
+	s = counters.Snapshot()
+	if n := s.GkeysAssigned - before.GkeysAssigned; n != 1 {
+		t.Errorf("Expected 1 gkey allocated, but was %v", n)
+	}
+	// GetGkey begins a transaction too.
+	if n := s.Transactions - before.Transactions; n != 2 {
+		t.Errorf("Expected 2 transactions, but was %v", n)
+	}
+	if n := s.Rollbacks - before.Rollbacks; n != 1 {
+		t.Errorf("Expected 1 rollback, but was %v", n)
+	}
+	if n := s.QueryErrors - before.QueryErrors; n != 1 {
+		t.Errorf("Expected 1 query error, but was %v", n)
+	}
+}
+
+func TestMetrics_PatchError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	counters := NewCounters()
+	patchFuncs := []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return errors.New("bad patch")
+		}},
+	}
+	sdb, err := OpenAndPatchDb(testDbName, patchFuncs, WithMetrics(counters))
+	if err == nil {
+		t.Error("Expected patch error")
+	}
+	defer closeDb(t, &sdb)
+
+	if s := counters.Snapshot(); s.PatchErrors != 1 {
+		t.Errorf("Expected 1 patch error, but was %v", s.PatchErrors)
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testDbName, nil, WithMetrics(nil))
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if _, err := sdb.GetGkey(); err != nil {
+		t.Errorf("GetGkey error: %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build minimal
+
+package sqldb
+
+// Minimal reports whether the package was built with the minimal build tag.
+// The minimal build compiles out the full-text search helpers, the table
+// exporters and importers, and the expvar and Prometheus metrics exporters.
+const Minimal = true
@@ -0,0 +1,20 @@
+//go:build minimal
+
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMinimalBuild(t *testing.T) {
+	if !Minimal {
+		t.Error("Expected Minimal to be set by the minimal build tag")
+	}
+	sdbType := reflect.TypeOf(&SQLDb{})
+	for _, name := range []string{"CreateFTSTable", "SearchFTS", "ExportTable", "ImportTable"} {
+		if _, ok := sdbType.MethodByName(name); ok {
+			t.Errorf("Expected %s to be compiled out of the minimal build", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ExecNamed - Execute the statement, binding its :name and @name parameters to the values
+// in params. See BindNamed.
+func (sdb *SQLDb) ExecNamed(stmt string, params interface{}) error {
+	positional, args, err := sdb.BindNamed(stmt, params)
+	if err != nil {
+		return err
+	}
+	return sdb.Exec(positional, args...)
+}
+
+// QueryNamed - Query the database, binding its :name and @name parameters to the values in
+// params, and append a struct for each returned row to the slice pointed to by dest, as with
+// QueryStructs. See BindNamed.
+func (sdb *SQLDb) QueryNamed(dest interface{}, stmt string, params interface{}) error {
+	positional, args, err := sdb.BindNamed(stmt, params)
+	if err != nil {
+		return err
+	}
+	return sdb.QueryStructs(dest, positional, args...)
+}
+
+// BindNamed - Replace the :name and @name parameters of the statement with positional
+// parameters, and return the statement with the bound arguments in order. The params are a
+// map[string]interface{}, or a struct whose fields are named by their column names.
+// Parameters inside string literals, quoted identifiers, and comments are left alone.
+func (sdb *SQLDb) BindNamed(stmt string, params interface{}) (string, []interface{}, error) {
+	lookup, err := sdb.namedParams(params)
+	if err != nil {
+		return "", nil, err
+	}
+	var sb strings.Builder
+	var args []interface{}
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := quoteEnd(stmt, i)
+			sb.WriteString(stmt[i:end])
+			i = end
+		case strings.HasPrefix(stmt[i:], "--"):
+			end := strings.IndexByte(stmt[i:], '\n')
+			if end < 0 {
+				end = len(stmt) - i
+			}
+			sb.WriteString(stmt[i : i+end])
+			i += end
+		case strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				end = len(stmt) - i
+			} else {
+				end += 4
+			}
+			sb.WriteString(stmt[i : i+end])
+			i += end
+		case (c == ':' || c == '@') && i+1 < len(stmt) && isNameStart(stmt[i+1]):
+			end := i + 1
+			for end < len(stmt) && isNamePart(stmt[end]) {
+				end++
+			}
+			name := stmt[i+1 : end]
+			v, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("dberror: no value for parameter %s in %s", stmt[i:end], stmt)
+			}
+			sb.WriteByte('?')
+			args = append(args, v)
+			i = end
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String(), args, nil
+}
+
+// namedParams returns a function to look up the value of a named parameter.
+func (sdb *SQLDb) namedParams(params interface{}) (func(name string) (interface{}, bool), error) {
+	if m, ok := params.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(params))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dberror: %T is not a map[string]interface{} or a struct", params)
+	}
+	fields := sdb.mapper.structFields(rv.Type())
+	return func(name string) (interface{}, bool) {
+		for _, f := range fields {
+			if strings.EqualFold(f.column, name) {
+				return f.bindValue(rv), true
+			}
+		}
+		return nil, false
+	}, nil
+}
+
+// quoteEnd returns the index just past the quoted string or identifier starting at i.
+// Quotes are escaped by doubling them.
+func quoteEnd(stmt string, i int) int {
+	closing := stmt[i]
+	if closing == '[' {
+		closing = ']'
+	}
+	for j := i + 1; j < len(stmt); j++ {
+		if stmt[j] == closing {
+			if closing != ']' && j+1 < len(stmt) && stmt[j+1] == closing {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(stmt)
+}
+
+// hasTail reports whether the statement has text after a semicolon, other than whitespace and
+// comments. The text may be another statement, or the rest of a trigger body.
+func hasTail(stmt string) bool {
+	semicolon := false
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(stmt[i:], "--"):
+			end := strings.IndexByte(stmt[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 4
+		case semicolon:
+			return true
+		case c == ';':
+			semicolon = true
+			i++
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			i = quoteEnd(stmt, i)
+		default:
+			i++
+		}
+	}
+	return false
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
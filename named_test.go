@@ -0,0 +1,82 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	sdb := &SQLDb{mapper: newStructMapper(nil)}
+	params := map[string]interface{}{"id": 1, "name": "a"}
+	for _, test := range []struct {
+		stmt     string
+		expected string
+		args     int
+	}{
+		{"UPDATE t SET name = :name WHERE id = @id", "UPDATE t SET name = ? WHERE id = ?", 2},
+		{"SELECT * FROM t WHERE id = :id OR parent = :id", "SELECT * FROM t WHERE id = ? OR parent = ?", 2},
+		{"SELECT ':id', \"@name\", [:x] FROM t -- :comment\nWHERE id = :id /* @name */",
+			"SELECT ':id', \"@name\", [:x] FROM t -- :comment\nWHERE id = ? /* @name */", 1},
+		{"SELECT 'it''s :id' WHERE id = :id", "SELECT 'it''s :id' WHERE id = ?", 1},
+		{"SELECT time('12:00')", "SELECT time('12:00')", 0},
+	} {
+		stmt, args, err := sdb.BindNamed(test.stmt, params)
+		if err != nil || stmt != test.expected || len(args) != test.args {
+			t.Errorf("Expected %q with %v args, but was %q with %v: %v", test.expected, test.args, stmt, args, err)
+		}
+	}
+
+	if _, _, err := sdb.BindNamed("SELECT :missing", params); err == nil {
+		t.Error("Expected error for a missing parameter")
+	}
+	if _, _, err := sdb.BindNamed("SELECT :id", 1); err == nil {
+		t.Error("Expected error for invalid params")
+	}
+}
+
+func TestExecNamed(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	if err := sdb.ExecNamed("INSERT INTO testtable (id, name) VALUES (:id, :name)",
+		map[string]interface{}{"id": 1, "name": "a"}); err != nil {
+		t.Errorf("ExecNamed map error: %v", err)
+	}
+	if err := sdb.ExecNamed("INSERT INTO testtable (id, name) VALUES (@id, @name)",
+		&testPageRow{ID: 2, Name: "b"}); err != nil {
+		t.Errorf("ExecNamed struct error: %v", err)
+	}
+
+	var rows []testPageRow
+	if err := sdb.QueryNamed(&rows, "SELECT id, name FROM testtable WHERE id >= :min ORDER BY id",
+		map[string]interface{}{"min": 1}); err != nil {
+		t.Fatalf("QueryNamed error: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "a" || rows[1].ID != 2 || rows[1].Name != "b" {
+		t.Errorf("Expected rows a and b, but was %v", rows)
+	}
+}
+
+func TestHasTail(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected bool
+	}{
+		{"INSERT INTO t (id) VALUES (1)", false},
+		{"INSERT INTO t (id) VALUES (1);", false},
+		{"INSERT INTO t (id) VALUES (1); -- done\n /* really */ ", false},
+		{"INSERT INTO t (name) VALUES ('a; b')", false},
+		{"INSERT INTO t (id) VALUES (1); INSERT INTO t (id) VALUES (2)", true},
+		{"CREATE TRIGGER t_ad AFTER DELETE ON t BEGIN DELETE FROM u; END", true},
+	}
+	for _, test := range tests {
+		if tail := hasTail(test.stmt); tail != test.expected {
+			t.Errorf("Expected hasTail(%q) to be %v, but was %v", test.stmt, test.expected, tail)
+		}
+	}
+}
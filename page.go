@@ -0,0 +1,216 @@
+package sqldb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// ErrBadPageToken is returned by QueryPage when the page token cannot be decoded,
+// or was not returned for the same kind of pagination.
+var ErrBadPageToken = errors.New("dberror: invalid page token")
+
+// ErrNullPageKey is returned by QueryPage when a row has a NULL key column for keyset pagination,
+// since no page can start after a NULL key.
+var ErrNullPageKey = errors.New("dberror: key column is NULL")
+
+// PageQuery - A query to return one page at a time.
+type PageQuery struct {
+	// Query is the base SELECT statement.
+	Query string
+	// Args are the bound arguments of the base query.
+	Args []interface{}
+	// PageSize is the maximum number of rows on each page.
+	PageSize int
+	// KeyColumn selects keyset pagination. The rows are ordered by the column, which must
+	// be unique and not NULL, and each page starts after the last key of the previous page.
+	// This stays fast and consistent however deep the page is. The column may hold integers,
+	// reals, text, or blobs. A page with a NULL key returns ErrNullPageKey. Without a key
+	// column, pages are read with LIMIT and OFFSET, in the order given by the base query.
+	KeyColumn string
+	// Descending orders the rows by descending key, for keyset pagination.
+	Descending bool
+	// Token is the next page token returned for the previous page, or empty for the first page.
+	Token string
+}
+
+type pageToken struct {
+	Offset int         `json:"o,omitempty"`
+	Key    interface{} `json:"k,omitempty"`
+	Blob   bool        `json:"b,omitempty"`
+}
+
+// QueryPage - Query a page of rows, and append a struct for each row to the slice pointed
+// to by dest, as with QueryStructs. Returns the token for the next page, which is empty
+// when there are no more rows.
+func (sdb *SQLDb) QueryPage(dest interface{}, page PageQuery) (next string, err error) {
+	defer sdb.recoverPanic(&err)
+	if page.PageSize <= 0 {
+		return "", fmt.Errorf("dberror: invalid page size %d", page.PageSize)
+	}
+	slice, err := newStructSlice(dest)
+	if err != nil {
+		return "", err
+	}
+	var token pageToken
+	if page.Token != "" {
+		if token, err = decodePageToken(page.Token); err != nil {
+			return "", err
+		}
+	}
+
+	stmt, args := page.Query, append([]interface{}{}, page.Args...)
+	keyset := page.KeyColumn != ""
+	if keyset {
+		if token.Offset != 0 {
+			return "", ErrBadPageToken
+		}
+		op, order := ">", "ASC"
+		if page.Descending {
+			op, order = "<", "DESC"
+		}
+		stmt = fmt.Sprintf("SELECT * FROM (%s)", strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		if token.Key != nil {
+			stmt += fmt.Sprintf(" WHERE %s %s ?", page.KeyColumn, op)
+			args = append(args, token.Key)
+		}
+		stmt += fmt.Sprintf(" ORDER BY %s %s LIMIT ?", page.KeyColumn, order)
+		args = append(args, page.PageSize+1)
+	} else {
+		if token.Key != nil {
+			return "", ErrBadPageToken
+		}
+		stmt = fmt.Sprintf("%s LIMIT ? OFFSET ?", strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		args = append(args, page.PageSize+1, token.Offset)
+	}
+
+	if args, err = convertArgs(args); err != nil {
+		return "", err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return "", fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	keyIndex := -1
+	var lastKey interface{}
+	count := 0
+	for rows.Next() {
+		// The extra row only shows that there is another page.
+		if count == page.PageSize {
+			if keyset {
+				return encodePageToken(pageToken{Key: lastKey})
+			}
+			return encodePageToken(pageToken{Offset: token.Offset + count})
+		}
+		if err := slice.append(sdb.mapper, rows); err != nil {
+			return "", fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+		}
+		if keyset {
+			if keyIndex < 0 {
+				if keyIndex, err = columnIndex(rows, page.KeyColumn); err != nil {
+					return "", err
+				}
+			}
+			if lastKey, err = scanColumn(rows, keyIndex); err != nil {
+				return "", fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+			}
+			if lastKey == nil {
+				return "", fmt.Errorf("dberror: paging by %s: %w", page.KeyColumn, ErrNullPageKey)
+			}
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return "", nil
+}
+
+// columnIndex returns the index of the named column in the rows.
+func columnIndex(rows *sql.Rows, column string) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return -1, err
+	}
+	for i, c := range columns {
+		if strings.EqualFold(c, column) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("dberror: query has no column %s", column)
+}
+
+// scanColumn scans the value of one column of the current row.
+func scanColumn(rows *sql.Rows, index int) (interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return values[index], nil
+}
+
+func encodePageToken(token pageToken) (string, error) {
+	switch key := token.Key.(type) {
+	case []byte:
+		token.Blob = true
+	case time.Time:
+		// Compare against times as they are stored by the driver.
+		token.Key = key.Format(sqlite3.SQLiteTimestampFormats[0])
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(s string) (pageToken, error) {
+	var token pageToken
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return token, ErrBadPageToken
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&token); err != nil || token.Offset < 0 {
+		return token, ErrBadPageToken
+	}
+	switch key := token.Key.(type) {
+	case json.Number:
+		if i, err := key.Int64(); err == nil {
+			token.Key = i
+		} else if f, err := key.Float64(); err == nil {
+			token.Key = f
+		} else {
+			return token, ErrBadPageToken
+		}
+	case string:
+		if token.Blob {
+			blob, err := base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				return token, ErrBadPageToken
+			}
+			token.Key = blob
+		}
+	case nil:
+	default:
+		return token, ErrBadPageToken
+	}
+	return token, nil
+}
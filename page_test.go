@@ -0,0 +1,177 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+type testPageRow struct {
+	ID   int
+	Name string
+}
+
+func openPageTestDb(t *testing.T) *SQLDb {
+	sdb := openTestDb(t)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if err := sdb.Exec("INSERT INTO testtable (name) VALUES (?)", name); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+	return sdb
+}
+
+// readPages reads every page of the query, and returns the names in order and the number of pages.
+func readPages(t *testing.T, sdb *SQLDb, page PageQuery) (string, int) {
+	var names string
+	pages := 0
+	for {
+		var rows []testPageRow
+		next, err := sdb.QueryPage(&rows, page)
+		if err != nil {
+			t.Fatalf("QueryPage error: %v", err)
+		}
+		pages++
+		for _, row := range rows {
+			names += row.Name
+		}
+		if next == "" {
+			return names, pages
+		}
+		if len(rows) != page.PageSize {
+			t.Errorf("Expected a full page before the last, but was %v rows", len(rows))
+		}
+		page.Token = next
+	}
+}
+
+func TestQueryPage_Offset(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPageTestDb(t)
+	defer closeDb(t, &sdb)
+
+	names, pages := readPages(t, sdb, PageQuery{
+		Query:    "SELECT id, name FROM testtable WHERE id > ? ORDER BY name DESC",
+		Args:     []interface{}{1},
+		PageSize: 2,
+	})
+	if names != "edcb" || pages != 2 {
+		t.Errorf("Expected edcb in 2 pages, but was %s in %v", names, pages)
+	}
+}
+
+func TestQueryPage_Keyset(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPageTestDb(t)
+	defer closeDb(t, &sdb)
+
+	page := PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 2, KeyColumn: "id"}
+	names, pages := readPages(t, sdb, page)
+	if names != "abcde" || pages != 3 {
+		t.Errorf("Expected abcde in 3 pages, but was %s in %v", names, pages)
+	}
+	page.Descending = true
+	names, pages = readPages(t, sdb, page)
+	if names != "edcba" || pages != 3 {
+		t.Errorf("Expected edcba in 3 pages, but was %s in %v", names, pages)
+	}
+	page = PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 5, KeyColumn: "name"}
+	names, pages = readPages(t, sdb, page)
+	if names != "abcde" || pages != 1 {
+		t.Errorf("Expected abcde in 1 page, but was %s in %v", names, pages)
+	}
+}
+
+func TestQueryPage_BlobKey(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id BLOB PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		if err := sdb.Exec("INSERT INTO testtable (id, name) VALUES (?, ?)", []byte{0, byte(i), 0xff}, name); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	page := PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 2, KeyColumn: "id"}
+	var names string
+	for {
+		var rows []struct {
+			ID   []byte
+			Name string
+		}
+		next, err := sdb.QueryPage(&rows, page)
+		if err != nil {
+			t.Fatalf("QueryPage error: %v", err)
+		}
+		for _, row := range rows {
+			names += row.Name
+		}
+		if next == "" {
+			break
+		}
+		page.Token = next
+	}
+	if names != "abc" {
+		t.Errorf("Expected abc, but was %s", names)
+	}
+}
+
+func TestQueryPage_BadToken(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPageTestDb(t)
+	defer closeDb(t, &sdb)
+
+	var rows []testPageRow
+	next, err := sdb.QueryPage(&rows, PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 2})
+	if err != nil || next == "" {
+		t.Fatalf("QueryPage error: %v", err)
+	}
+	// An offset token cannot be used for keyset pagination.
+	_, err = sdb.QueryPage(&rows, PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 2, KeyColumn: "id", Token: next})
+	if !errors.Is(err, ErrBadPageToken) {
+		t.Errorf("Expected ErrBadPageToken, but was %v", err)
+	}
+	_, err = sdb.QueryPage(&rows, PageQuery{Query: "SELECT id, name FROM testtable", PageSize: 2, Token: "not a token"})
+	if !errors.Is(err, ErrBadPageToken) {
+		t.Errorf("Expected ErrBadPageToken, but was %v", err)
+	}
+	if _, err = sdb.QueryPage(&rows, PageQuery{Query: "SELECT id, name FROM testtable"}); err == nil {
+		t.Error("Expected error for a zero page size")
+	}
+}
+
+func TestQueryPage_NullKey(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPageTestDb(t)
+	defer closeDb(t, &sdb)
+
+	type nullKeyRow struct {
+		Key  *int
+		Name string
+	}
+	// A next page token with a NULL key would start over at the first page.
+	var rows []nullKeyRow
+	_, err := sdb.QueryPage(&rows, PageQuery{
+		Query:     "SELECT nullif(id, 1) AS key, name FROM testtable",
+		PageSize:  2,
+		KeyColumn: "key",
+	})
+	if !errors.Is(err, ErrNullPageKey) {
+		t.Errorf("Expected ErrNullPageKey, but was %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package sqldb
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError - Error converted from a panic recovered inside a database call or callback.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("dberror: recovered panic: %v\n%s", pe.Value, pe.Stack)
+}
+
+// Unwrap - Return the panic value if it was an error.
+func (pe *PanicError) Unwrap() error {
+	if err, ok := pe.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// WithRecoverPanics - Recover panics raised inside callbacks and driver code, and return
+// them as a *PanicError instead. Any transaction or save point wrapping the callback is
+// rolled back. Intended for long-running daemons that must not crash on a bad callback.
+func WithRecoverPanics() Option {
+	return func(sdb *SQLDb) {
+		sdb.recoverPanics = true
+	}
+}
+
+// recoverPanic converts a panic into a *PanicError stored in err when panic recovery is enabled.
+// It must be called directly by defer.
+func (sdb *SQLDb) recoverPanic(err *error) {
+	if !sdb.recoverPanics {
+		return
+	}
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
+
+// call runs the callback, converting a panic into an error when panic recovery is enabled.
+func (sdb *SQLDb) call(fn func() error) (err error) {
+	defer sdb.recoverPanic(&err)
+	return fn()
+}
+
+// callOrRollback runs the callback like call. If it panics and panic recovery is not enabled,
+// rollback is called before the panic continues, so that it does not leave a transaction or
+// save point open on the database.
+func (sdb *SQLDb) callOrRollback(fn func() error, rollback func()) (err error) {
+	if sdb.recoverPanics {
+		return sdb.call(fn)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			panic(r)
+		}
+	}()
+	return fn()
+}
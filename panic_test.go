@@ -0,0 +1,175 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func openRecoverTestDb(t *testing.T) *SQLDb {
+	sdb, err := OpenAndPatchDb(testDbName, nil, WithRecoverPanics())
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	return sdb
+}
+
+func TestRecoverPanics_ExecWithSavePoint(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openRecoverTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	panicErr := errors.New("callback failure")
+	err := sdb.ExecWithSavePoint("panictest", func() error {
+		if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			return err
+		}
+		panic(panicErr)
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected PanicError, but was %v", err)
+	}
+	if len(pe.Stack) == 0 {
+		t.Error("Expected PanicError to have a stack trace")
+	}
+	if !errors.Is(err, panicErr) {
+		t.Error("Expected PanicError to wrap the panic value")
+	}
+
+	exists, err := sdb.QueryExists("SELECT id FROM testtable")
+	if err != nil || exists {
+		t.Errorf("Expected save point to be rolled back: %v, %v", exists, err)
+	}
+}
+
+func TestRecoverPanics_MultiQuery(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openRecoverTestDb(t)
+	defer closeDb(t, &sdb)
+
+	err := sdb.MultiQuery("SELECT patchid FROM version", func(rows *sql.Rows) error {
+		panic("bad row")
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "bad row" {
+		t.Errorf("Expected PanicError, but was %v", err)
+	}
+}
+
+func TestRecoverPanics_PatchFunc(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openRecoverTestDb(t)
+	defer closeDb(t, &sdb)
+
+	err := sdb.PatchDb([]PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			panic("bad patch")
+		}},
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("Expected PanicError, but was %v", err)
+	}
+	patched, err := sdb.patched(1)
+	if err != nil || patched {
+		t.Errorf("Expected patch to not be applied: %v, %v", patched, err)
+	}
+}
+
+func TestRecoverPanics_Disabled(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic to propagate without WithRecoverPanics")
+		}
+	}()
+	sdb.MultiQuery("SELECT patchid FROM version", func(rows *sql.Rows) error {
+		panic("bad row")
+	})
+}
+
+// expectPanic calls fn, and fails the test if it does not panic with the value.
+func expectPanic(t *testing.T, value interface{}, fn func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != value {
+			t.Errorf("Expected panic %v, but was %v", value, r)
+		}
+	}()
+	fn()
+}
+
+func TestPanicRollback(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	insertAndPanic := func() error {
+		if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			return err
+		}
+		panic("callback failure")
+	}
+	expectPanic(t, "callback failure", func() {
+		sdb.ExecWithSavePoint("panictest", insertAndPanic)
+	})
+	expectPanic(t, "callback failure", func() {
+		sdb.NestedTxn(insertAndPanic)
+	})
+	expectPanic(t, "callback failure", func() {
+		sdb.WithTransaction(func(tx *SQLDb) error {
+			if err := tx.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+				return err
+			}
+			panic("callback failure")
+		})
+	})
+	expectPanic(t, "bad patch", func() {
+		sdb.PatchDb([]PatchFuncType{
+			{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+				if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+					return err
+				}
+				panic("bad patch")
+			}},
+		})
+	})
+
+	if !sdb.activity.idle() {
+		t.Error("Expected no open transaction after panics")
+	}
+	exists, err := sdb.QueryExists("SELECT id FROM testtable")
+	if err != nil || exists {
+		t.Errorf("Expected inserts to be rolled back: %v, %v", exists, err)
+	}
+	patched, err := sdb.patched(1)
+	if err != nil || patched {
+		t.Errorf("Expected patch to not be applied: %v, %v", patched, err)
+	}
+	// The database is still usable in a new transaction.
+	if err := sdb.WithTransaction(func(tx *SQLDb) error {
+		return tx.Exec("INSERT INTO testtable (id) VALUES (2)")
+	}); err != nil {
+		t.Errorf("WithTransaction error: %v", err)
+	}
+}
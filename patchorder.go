@@ -0,0 +1,68 @@
+package sqldb
+
+import (
+	"fmt"
+	"sort"
+)
+
+// orderPatches returns the patches in the order they are given, except that each is moved
+// after the patches it depends on. Returns an error for duplicate patch IDs, and, when any
+// patch has dependencies, for dependencies on patches that are not given and dependency cycles.
+func orderPatches(patchFuncs []PatchFuncType) ([]PatchFuncType, error) {
+	index := make(map[int]bool, len(patchFuncs))
+	hasDeps := false
+	for _, patch := range patchFuncs {
+		if index[patch.PatchID] {
+			return nil, fmt.Errorf("dberror: duplicate patch %d", patch.PatchID)
+		}
+		index[patch.PatchID] = true
+		hasDeps = hasDeps || len(patch.DependsOn) > 0
+	}
+	if !hasDeps {
+		return patchFuncs, nil
+	}
+	for _, patch := range patchFuncs {
+		for _, dep := range patch.DependsOn {
+			if !index[dep] {
+				return nil, fmt.Errorf("dberror: patch %d depends on missing patch %d", patch.PatchID, dep)
+			}
+		}
+	}
+
+	// Repeatedly take the first remaining patch whose dependencies have all been taken,
+	// so patches keep their given order wherever the dependencies allow.
+	ordered := make([]PatchFuncType, 0, len(patchFuncs))
+	placed := make(map[int]bool, len(patchFuncs))
+	for len(ordered) < len(patchFuncs) {
+		progress := false
+		for _, patch := range patchFuncs {
+			if placed[patch.PatchID] || !allPlaced(patch.DependsOn, placed) {
+				continue
+			}
+			ordered = append(ordered, patch)
+			placed[patch.PatchID] = true
+			progress = true
+			break
+		}
+		if !progress {
+			var cycle []int
+			for _, patch := range patchFuncs {
+				if !placed[patch.PatchID] {
+					cycle = append(cycle, patch.PatchID)
+				}
+			}
+			sort.Ints(cycle)
+			return nil, fmt.Errorf("dberror: dependency cycle among patches %v", cycle)
+		}
+	}
+	return ordered, nil
+}
+
+func allPlaced(ids []int, placed map[int]bool) bool {
+	for _, id := range ids {
+		if !placed[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package sqldb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func patchIDs(patchFuncs []PatchFuncType) []int {
+	ids := make([]int, len(patchFuncs))
+	for i, patch := range patchFuncs {
+		ids[i] = patch.PatchID
+	}
+	return ids
+}
+
+func TestOrderPatches(t *testing.T) {
+	ordered, err := orderPatches([]PatchFuncType{
+		{PatchID: 1},
+		{PatchID: 4, DependsOn: []int{3}},
+		{PatchID: 2},
+		{PatchID: 3, DependsOn: []int{1, 2}},
+		{PatchID: 5},
+	})
+	if err != nil {
+		t.Fatalf("orderPatches error: %v", err)
+	}
+	if ids := patchIDs(ordered); !reflect.DeepEqual(ids, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Expected [1 2 3 4 5], but was %v", ids)
+	}
+
+	tests := []struct {
+		patches []PatchFuncType
+		err     string
+	}{
+		{[]PatchFuncType{{PatchID: 1, DependsOn: []int{2}}}, "missing patch 2"},
+		{[]PatchFuncType{{PatchID: 1, DependsOn: []int{2}}, {PatchID: 2, DependsOn: []int{1}}, {PatchID: 3}}, "cycle among patches [1 2]"},
+		{[]PatchFuncType{{PatchID: 1}, {PatchID: 2, DependsOn: []int{1}}, {PatchID: 1}}, "duplicate patch 1"},
+		{[]PatchFuncType{{PatchID: 1}, {PatchID: 2}, {PatchID: 1}}, "duplicate patch 1"},
+	}
+	for _, test := range tests {
+		if _, err := orderPatches(test.patches); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected error %q, but was %v", test.err, err)
+		}
+	}
+}
+
+func TestPatchDb_DependsOn(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testDbName, []PatchFuncType{
+		{PatchID: 2, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateIndex("test_idx ON testtable (id)")
+		}, DependsOn: []int{1}},
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+	})
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if exists, err := sdb.IndexExists("test_idx"); err != nil || !exists {
+		t.Errorf("Expected the dependent patch to be applied: %v, %v", exists, err)
+	}
+
+	err = sdb.PatchDb([]PatchFuncType{
+		{PatchID: 3, PatchFunc: func(sdb *SQLDb) error { return nil }, DependsOn: []int{4}},
+	})
+	if err == nil {
+		t.Error("PatchDb with a missing dependency did not return an error")
+	}
+	if patched, err := sdb.patched(3); err != nil || patched {
+		t.Errorf("Expected no patch applied with a missing dependency: %v, %v", patched, err)
+	}
+}
@@ -0,0 +1,123 @@
+package sqldb
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// PlanRow - One step of a query plan, as reported by EXPLAIN QUERY PLAN.
+type PlanRow struct {
+	// ID identifies the step. Parent is the ID of the step it is nested in, or zero.
+	ID     int
+	Parent int
+	// Detail describes the step, such as "SCAN users" or "SEARCH users USING INDEX users_name (name=?)".
+	Detail string
+}
+
+// FullScan - Report whether the step reads every row of a table without the help of an index.
+func (pr PlanRow) FullScan() bool {
+	return strings.HasPrefix(pr.Detail, "SCAN ") && !strings.Contains(pr.Detail, " INDEX ") &&
+		pr.Detail != "SCAN CONSTANT ROW"
+}
+
+// ExplainQueryPlan - Return the steps SQLite plans to take to run the statement with the bound
+// arguments, without running it.
+func (sdb *SQLDb) ExplainQueryPlan(stmt string, args ...interface{}) ([]PlanRow, error) {
+	args, err := convertArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := sdb.query("EXPLAIN QUERY PLAN "+stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	var plan []PlanRow
+	for rows.Next() {
+		var row PlanRow
+		var unused int
+		if err := rows.Scan(&row.ID, &row.Parent, &unused, &row.Detail); err != nil {
+			return nil, err
+		}
+		plan = append(plan, row)
+	}
+	return plan, rows.Err()
+}
+
+// WarnOnFullScan - Log a warning to the logger for each query whose plan scans a whole table,
+// to help find missing indexes. Each distinct query is explained once, after it first succeeds
+// and its rows are closed, on the same connection. A nil logger uses the standard logger.
+func WarnOnFullScan(logger *log.Logger) Option {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(sdb *SQLDb) {
+		sdb.scans = &scanWarner{logger: logger, explained: &sync.Map{}}
+	}
+}
+
+// scanWarner queues the queries of a handle to be explained once their rows are closed.
+// Explaining a query while its rows are still open could wait forever for a connection.
+type scanWarner struct {
+	logger    *log.Logger
+	explained *sync.Map
+	mu        sync.Mutex
+	pending   []pendingPlan
+}
+
+type pendingPlan struct {
+	stmt string
+	args []interface{}
+}
+
+// forConn returns a scanWarner for a handle bound to a connection, which explains its
+// queries on that connection.
+func (sw *scanWarner) forConn() *scanWarner {
+	return &scanWarner{logger: sw.logger, explained: sw.explained}
+}
+
+// queue queues the query to be explained, unless it has been already.
+func (sw *scanWarner) queue(stmt string, args []interface{}) {
+	if !isQuery(stmt) {
+		return
+	}
+	if _, seen := sw.explained.LoadOrStore(stmt, true); seen {
+		return
+	}
+	sw.mu.Lock()
+	sw.pending = append(sw.pending, pendingPlan{stmt: stmt, args: args})
+	sw.mu.Unlock()
+}
+
+// explain explains the queued queries through the handle, and logs any full table scans.
+func (sw *scanWarner) explain(sdb *SQLDb) {
+	sw.mu.Lock()
+	pending := sw.pending
+	sw.pending = nil
+	sw.mu.Unlock()
+	for _, pp := range pending {
+		plan, err := sdb.ExplainQueryPlan(pp.stmt, pp.args...)
+		if err != nil {
+			continue
+		}
+		for _, row := range plan {
+			if row.FullScan() {
+				sw.logger.Printf("dbscan: %s %v: %s", pp.stmt, pp.args, row.Detail)
+			}
+		}
+	}
+}
+
+// isQuery reports whether the statement is a SELECT query, which could scan tables.
+func isQuery(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package sqldb
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExplainQueryPlan(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("test_idx ON testtable (name)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+
+	plan, err := sdb.ExplainQueryPlan("SELECT id FROM testtable WHERE id = ?", 1)
+	if err != nil {
+		t.Fatalf("ExplainQueryPlan error: %v", err)
+	}
+	if len(plan) != 1 || !plan[0].FullScan() {
+		t.Errorf("Expected a full scan, but was %v", plan)
+	}
+
+	plan, err = sdb.ExplainQueryPlan("SELECT id FROM testtable WHERE name = ?", "a")
+	if err != nil {
+		t.Fatalf("ExplainQueryPlan error: %v", err)
+	}
+	if len(plan) != 1 || plan[0].FullScan() || !strings.Contains(plan[0].Detail, "test_idx") {
+		t.Errorf("Expected an index search, but was %v", plan)
+	}
+
+	if _, err := sdb.ExplainQueryPlan("SELECT id FROM notatable"); err == nil {
+		t.Error("ExplainQueryPlan of a missing table did not return an error")
+	}
+}
+
+func TestWarnOnFullScan(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	var buf bytes.Buffer
+	sdb, err := OpenDb(testDbName, WarnOnFullScan(log.New(&buf, "", 0)))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	var exists bool
+	for i := 0; i < 2; i++ {
+		if exists, err = sdb.QueryExists("SELECT id FROM testtable WHERE name = ?", "a"); err != nil || exists {
+			t.Errorf("QueryExists error: %v, %v", exists, err)
+		}
+	}
+	if exists, err = sdb.QueryExists("SELECT id FROM testtable WHERE id = ?", 1); err != nil || exists {
+		t.Errorf("QueryExists error: %v, %v", exists, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "dbscan: SELECT id FROM testtable WHERE name = ?") {
+		t.Errorf("Expected one full scan warning, but was %q", buf.String())
+	}
+}
+
+func TestWarnOnFullScan_OneConnection(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	var sdb *SQLDb
+	go func() {
+		var err error
+		sdb, err = OpenAndPatchDb(testDbName, []PatchFuncType{
+			{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+				if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+					return err
+				}
+				_, err := sdb.QueryExists("SELECT id FROM testtable WHERE name = ?", "a")
+				return err
+			}},
+		}, WithMaxOpenConns(1), WarnOnFullScan(log.New(&buf, "", 0)))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("OpenAndPatchDb error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("OpenAndPatchDb did not return")
+	}
+	defer closeDb(t, &sdb)
+
+	if !strings.Contains(buf.String(), "dbscan: SELECT id FROM testtable WHERE name = ?") {
+		t.Errorf("Expected a full scan warning from the patch, but was %q", buf.String())
+	}
+}
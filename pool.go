@@ -0,0 +1,198 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrWriterClosed is returned for writes queued after the single writer has been closed.
+var ErrWriterClosed = errors.New("dberror: single writer is closed")
+
+// ErrSingleWriterTx is returned when a transaction or save point is begun on a single writer
+// handle itself, which every goroutine shares. Run transactions with InTx instead.
+var ErrSingleWriterTx = errors.New("dberror: transactions in single writer mode must run with InTx")
+
+// WithMaxOpenConns - Limit the number of open connections to the database.
+// See sql.DB.SetMaxOpenConns.
+func WithMaxOpenConns(n int) Option {
+	return poolOption(func(db *sql.DB) {
+		db.SetMaxOpenConns(n)
+	})
+}
+
+// WithMaxIdleConns - Limit the number of idle connections kept open for reuse.
+// See sql.DB.SetMaxIdleConns.
+func WithMaxIdleConns(n int) Option {
+	return poolOption(func(db *sql.DB) {
+		db.SetMaxIdleConns(n)
+	})
+}
+
+// WithConnMaxLifetime - Close connections once they have been open for the duration.
+// See sql.DB.SetConnMaxLifetime.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return poolOption(func(db *sql.DB) {
+		db.SetConnMaxLifetime(d)
+	})
+}
+
+// poolOption returns an Option that configures the connection pool when it is opened.
+func poolOption(apply func(db *sql.DB)) Option {
+	return func(sdb *SQLDb) {
+		sdb.pool = append(sdb.pool, apply)
+	}
+}
+
+// WithSingleWriter - Serialize every write through one connection, which a single goroutine
+// runs from a queue, so that writers never contend for the database lock. Queries run on the
+// other pooled connections, so they see only committed writes. A transaction runs as a single
+// job of the writer with InTx, so that writes from other goroutines wait until it ends, and its
+// statements must be run through the handle InTx passes. Beginning a transaction or save point
+// on the handle itself, as with BeginTrans or NestedTxn, returns
+// ErrSingleWriterTx. The package's own multi-statement helpers, patches, and seeds run as
+// writer jobs. The writer holds one connection open, so a limit set WithMaxOpenConns must be
+// at least two. Ignored for read-only databases.
+func WithSingleWriter() Option {
+	return func(sdb *SQLDb) {
+		sdb.singleWriter = true
+	}
+}
+
+// writer runs write jobs one at a time on a dedicated connection.
+type writer struct {
+	conn *sql.Conn
+	jobs chan writeJob
+	done chan struct{}
+	// mu guards closed, and is held for reading while a job is queued.
+	mu     sync.RWMutex
+	closed bool
+}
+
+type writeJob struct {
+	fn     func(conn *sql.Conn) error
+	result chan writeResult
+}
+
+type writeResult struct {
+	err error
+	// panicked is the value a job panicked with, which is passed on to the goroutine that queued it.
+	panicked interface{}
+}
+
+func newWriter(db *sql.DB) (*writer, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("dberror: could not open single writer connection: %v", err)
+	}
+	w := &writer{conn: conn, jobs: make(chan writeJob), done: make(chan struct{})}
+	go w.run()
+	return w, nil
+}
+
+func (w *writer) run() {
+	defer close(w.done)
+	for job := range w.jobs {
+		job.result <- w.call(job.fn)
+	}
+}
+
+func (w *writer) call(fn func(conn *sql.Conn) error) (res writeResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			res.panicked = r
+		}
+	}()
+	return writeResult{err: fn(w.conn)}
+}
+
+// do queues the job, and waits for the writer to run it.
+func (w *writer) do(fn func(conn *sql.Conn) error) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return ErrWriterClosed
+	}
+	result := make(chan writeResult, 1)
+	w.jobs <- writeJob{fn: fn, result: result}
+	w.mu.RUnlock()
+	res := <-result
+	if res.panicked != nil {
+		panic(res.panicked)
+	}
+	return res.err
+}
+
+// close stops the writer once the queued jobs have run, and closes its connection.
+func (w *writer) close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.jobs)
+	w.mu.Unlock()
+	<-w.done
+	return w.conn.Close()
+}
+
+// dedicated runs the function with a handle bound to a single connection: the writer
+// connection in single writer mode, or else a connection taken from the pool.
+func (sdb *SQLDb) dedicated(fn func(tx *SQLDb) error) error {
+	if sdb.conn != nil {
+		return fn(sdb)
+	}
+	if sdb.writer != nil {
+		return sdb.writer.do(func(conn *sql.Conn) error {
+			return fn(sdb.withConn(conn))
+		})
+	}
+	conn, err := sdb.DB.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(sdb.withConn(conn))
+}
+
+// closeDB closes the single writer, if any, and the connection pool.
+func (sdb *SQLDb) closeDB() error {
+	var writerErr error
+	if sdb.writer != nil {
+		writerErr = sdb.writer.close()
+	}
+	return errors.Join(writerErr, sdb.DB.Close())
+}
+
+// writeTx runs fn with a handle for running a transaction on one connection: the handle itself
+// if it is bound to a connection or already has a transaction open, or else a dedicated
+// connection, which in single writer mode is one job of the writer, so that other writes wait
+// until it is done.
+func (sdb *SQLDb) writeTx(fn func(tx *SQLDb) error) error {
+	if sdb.conn != nil || sdb.activity.inTransaction(sdb.txn) {
+		return fn(sdb)
+	}
+	return sdb.dedicated(fn)
+}
+
+// inSavePoint runs fn inside a save point on the handle given by writeTx.
+func (sdb *SQLDb) inSavePoint(fn func(tx *SQLDb) error) error {
+	return sdb.writeTx(func(tx *SQLDb) error {
+		return tx.NestedTxn(func() error {
+			return fn(tx)
+		})
+	})
+}
+
+// checkTxHandle refuses to begin a transaction on a single writer handle that is not bound
+// to the writer connection.
+func (sdb *SQLDb) checkTxHandle() error {
+	if sdb.writer != nil && sdb.conn == nil {
+		return ErrSingleWriterTx
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package sqldb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolOptions(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testDbName, WithMaxOpenConns(3), WithMaxIdleConns(1), WithConnMaxLifetime(time.Minute))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if max := sdb.Stats().MaxOpenConnections; max != 3 {
+		t.Errorf("Expected 3 max open connections, but was %v", max)
+	}
+}
+
+func TestSingleWriter(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	// Without a busy timeout, concurrent writers on separate connections would fail to lock.
+	sdb, err := OpenAndPatchDb(testNoWaitDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+	}, WithSingleWriter())
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if err := sdb.Exec("INSERT INTO testtable (id) VALUES (?)", i*10+j); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Exec error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", ""); err != nil || count != 100 {
+		t.Errorf("Expected 100 rows, but was %v: %v", count, err)
+	}
+
+	// Queries inside a transaction see its uncommitted writes.
+	err = sdb.InTx(func(tx *SQLTx) error {
+		if err := tx.Exec("INSERT INTO testtable (id) VALUES (100)"); err != nil {
+			return err
+		}
+		if exists, err := tx.Exists("testtable", "id = ?", 100); err != nil || !exists {
+			t.Errorf("Expected the uncommitted row: %v, %v", exists, err)
+		}
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Error("Expected the transaction error")
+	}
+	if exists, err := sdb.Exists("testtable", "id = ?", 100); err != nil || exists {
+		t.Errorf("Expected the row to be rolled back: %v, %v", exists, err)
+	}
+
+	// Transactions cannot be begun on the handle that every goroutine shares.
+	if err := sdb.BeginTrans(); !errors.Is(err, ErrSingleWriterTx) {
+		t.Errorf("Expected ErrSingleWriterTx, but was %v", err)
+	}
+	// WithTransaction runs as a writer job, like InTx.
+	if err := sdb.WithTransaction(func(tx *SQLDb) error {
+		return tx.Exec("INSERT INTO testtable (id) VALUES (1)")
+	}); err != nil {
+		t.Errorf("WithTransaction error: %v", err)
+	}
+	if _, err := sdb.GetGkey(); err != nil {
+		t.Errorf("GetGkey error: %v", err)
+	}
+
+	// A panic in a patch is passed on from the writer to the caller.
+	expectPanic(t, "patch panic", func() {
+		sdb.PatchDb([]PatchFuncType{
+			{PatchID: 2, PatchFunc: func(sdb *SQLDb) error {
+				panic("patch panic")
+			}},
+		})
+	})
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (101)"); err != nil {
+		t.Errorf("Exec after panic error: %v", err)
+	}
+
+	if err := sdb.Close(); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (102)"); err == nil {
+		t.Error("Exec on a closed single writer did not return an error")
+	}
+	sdb = nil
+}
+
+func TestSingleWriter_Transactions(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testNoWaitDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+	}, WithSingleWriter())
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	// A write from another goroutine waits for the transaction, rather than joining it.
+	inserted := make(chan error, 1)
+	err = sdb.InTx(func(tx *SQLTx) error {
+		if err := tx.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			return err
+		}
+		go func() {
+			inserted <- sdb.Exec("INSERT INTO testtable (id) VALUES (2)")
+		}()
+		select {
+		case err := <-inserted:
+			t.Errorf("Expected the write to wait for the transaction, but it returned %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		if count, err := sdb.Count("testtable", ""); err != nil || count != 0 {
+			t.Errorf("Expected queries on other connections to see no rows, but was %v: %v", count, err)
+		}
+		return errors.New("rollback")
+	})
+	if err == nil {
+		t.Error("Expected the transaction error")
+	}
+	if err := <-inserted; err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+	var id int
+	if err := sdb.SingleQuery("SELECT id FROM testtable", &id); err != nil || id != 2 {
+		t.Errorf("Expected only the other goroutine's row 2, but was %v: %v", id, err)
+	}
+
+	// The package's own helpers run their save points as writer jobs.
+	err = sdb.ExecBatch([]BatchStmt{
+		{SQL: "INSERT INTO testtable (id) VALUES (3)"},
+		{SQL: "INSERT INTO testtable (id) VALUES (4)"},
+	})
+	if err != nil {
+		t.Errorf("ExecBatch error: %v", err)
+	}
+	if count, err := sdb.Count("testtable", ""); err != nil || count != 3 {
+		t.Errorf("Expected 3 rows, but was %v: %v", count, err)
+	}
+}
+
+func TestSingleWriter_SafePatch(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndSafePatchDb(testDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+	}, nil, WithSingleWriter())
+	if err != nil {
+		t.Fatalf("OpenAndSafePatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrReadOnly is returned by statements and patches that would modify a database
+// opened with OpenDbReadOnly.
+var ErrReadOnly = errors.New("dberror: database is read-only")
+
+// OpenDbReadOnly - Open an existing database for reading only. SQLite opens the file
+// read-only, and the SQLDb also refuses statements that would modify it, returning
+// ErrReadOnly. Transaction statements are still allowed, for consistent reads, and so is
+// VacuumInto, which only writes the copy.
+func OpenDbReadOnly(dbFilename string, opts ...Option) (*SQLDb, error) {
+	return OpenDb(readOnlyDsn(dbFilename), append([]Option{readOnly()}, opts...)...)
+}
+
+func readOnly() Option {
+	return func(sdb *SQLDb) {
+		sdb.readOnly = true
+	}
+}
+
+// readOnlyDsn returns the database DSN as a URI that opens the file read-only.
+func readOnlyDsn(dbFilename string) string {
+	if !strings.HasPrefix(dbFilename, "file:") {
+		dbFilename = "file:" + dbFilename
+	}
+	if strings.Contains(dbFilename, "?") {
+		return dbFilename + "&mode=ro"
+	}
+	return dbFilename + "?mode=ro"
+}
+
+// checkWritable returns ErrReadOnly if the database is read-only and the statement
+// is not transaction control or a VACUUM INTO, which only writes the copy.
+func (sdb *SQLDb) checkWritable(stmt string) error {
+	if !sdb.readOnly || isTransactionControl(stmt) || isVacuumInto(stmt) {
+		return nil
+	}
+	return ErrReadOnly
+}
+
+// isVacuumInto reports whether the statement vacuums a database into a new file.
+func isVacuumInto(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "VACUUM") {
+		return false
+	}
+	// The schema name is optional, as in VACUUM main INTO.
+	return strings.EqualFold(fields[1], "INTO") || (len(fields) > 2 && strings.EqualFold(fields[2], "INTO"))
+}
+
+// isTransactionControl reports whether the statement only begins or ends a transaction or save point.
+func isTransactionControl(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOpenDbReadOnly(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	closeDb(t, &sdb)
+
+	rdb, err := OpenDbReadOnly(testDbName)
+	if err != nil {
+		t.Fatalf("OpenDbReadOnly error: %v", err)
+	}
+	defer closeDb(t, &rdb)
+
+	var id int
+	if err := rdb.SingleQuery("SELECT id FROM testtable", &id); err != nil || id != 1 {
+		t.Errorf("Expected id 1, but was %v: %v", id, err)
+	}
+	if err := rdb.BeginTrans(); err != nil {
+		t.Errorf("BeginTrans error: %v", err)
+	} else if err := rdb.CommitTrans(); err != nil {
+		t.Errorf("CommitTrans error: %v", err)
+	}
+
+	if err := rdb.Exec("INSERT INTO testtable (id) VALUES (2)"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected Exec ErrReadOnly, but was %v", err)
+	}
+	if err := rdb.CreateTable("othertable (id INTEGER)"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected CreateTable ErrReadOnly, but was %v", err)
+	}
+	if err := rdb.DropTable("testtable"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected DropTable ErrReadOnly, but was %v", err)
+	}
+	if err := rdb.PatchDb(nil); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected PatchDb ErrReadOnly, but was %v", err)
+	}
+	// SQLite refuses writes that bypass the wrapper.
+	if _, err := rdb.DB.Exec("INSERT INTO testtable (id) VALUES (3)"); err == nil {
+		t.Error("Expected error writing through the read-only connection")
+	}
+}
+
+func TestOpenDbReadOnly_Missing(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDbReadOnly(testDbName)
+	if err == nil {
+		t.Error("Expected error opening a missing database read-only")
+	}
+	if sdb != nil && sdb.DB != nil {
+		sdb.Close()
+	}
+}
+
+func TestReadOnlyDsn(t *testing.T) {
+	for dsn, expected := range map[string]string{
+		"test.db":                        "file:test.db?mode=ro",
+		"file:test.db":                   "file:test.db?mode=ro",
+		"file:test.db?_journal_mode=WAL": "file:test.db?_journal_mode=WAL&mode=ro",
+	} {
+		if actual := readOnlyDsn(dsn); actual != expected {
+			t.Errorf("Expected %s, but was %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The prefix of the temporary table that a rebuilt table is copied into. It is an internal
+// name, so a table left behind by an interrupted rebuild is not taken for an application table.
+const rebuildTablePrefix = internalPrefix + "rebuild_"
+
+// ErrForeignKeysInTx is returned by RebuildTable inside a transaction while foreign keys are enforced,
+// since SQLite cannot suspend their enforcement there, and dropping the table would cascade.
+var ErrForeignKeysInTx = errors.New("dberror: cannot rebuild a table inside a transaction while foreign keys are enforced")
+
+// RebuildTable - Change the definition of a table in ways that ALTER TABLE does not support,
+// such as dropping or retyping columns, using SQLite's 12-step table rebuild.
+// newDef is the new column and constraint definition list, such as "(id INTEGER PRIMARY KEY, name TEXT)".
+// columnMap maps each new column to the expression that computes its value from the old row,
+// such as an old column name. With a nil columnMap, the columns with the same name in both
+// definitions are copied. The indexes and triggers on the table, and all views, are recreated.
+// The rebuild runs inside a save point, so it is undone if any step fails.
+// Foreign key enforcement is suspended during the rebuild, and the foreign keys are checked
+// before it is committed. SQLite cannot suspend it inside a transaction, such as in a patch,
+// so there ErrForeignKeysInTx is returned unless foreign keys are not enforced.
+func (sdb *SQLDb) RebuildTable(table string, newDef string, columnMap map[string]string) error {
+	if sdb.conn == nil && sdb.activity.inTransaction(sdb.txn) {
+		return sdb.rebuildTable(table, newDef, columnMap, true)
+	}
+	// Foreign key enforcement is a setting of the connection, so every step runs on one.
+	return sdb.dedicated(func(tx *SQLDb) error {
+		inTx, err := tx.connInTransaction()
+		if err != nil {
+			return err
+		}
+		return tx.rebuildTable(table, newDef, columnMap, inTx)
+	})
+}
+
+func (sdb *SQLDb) rebuildTable(table string, newDef string, columnMap map[string]string, inTx bool) error {
+	var foreignKeys bool
+	if err := sdb.SingleQuery("PRAGMA foreign_keys", &foreignKeys); err != nil {
+		return err
+	}
+	if foreignKeys {
+		if inTx {
+			return fmt.Errorf("dberror: rebuilding %s: %w", table, ErrForeignKeysInTx)
+		}
+		if err := sdb.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer sdb.Exec("PRAGMA foreign_keys = ON")
+	}
+
+	return sdb.NestedTxn(func() error {
+		exists, err := sdb.TableExists(table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("dberror: no such table to rebuild: %s", table)
+		}
+
+		// Save the definitions of the schema objects that are dropped along with the table,
+		// and of the views, which could otherwise block renaming the new table.
+		var schemaSQL []string
+		var views []string
+		err = sdb.MultiQueryArgs(`SELECT type, name, sql FROM sqlite_master
+			WHERE sql IS NOT NULL AND ((type IN ('index', 'trigger') AND tbl_name = ?) OR type = 'view')`,
+			[]interface{}{table}, func(rows *sql.Rows) (bool, error) {
+				var objType, name, objSQL string
+				if err := rows.Scan(&objType, &name, &objSQL); err != nil {
+					return false, err
+				}
+				if objType == "view" {
+					views = append(views, name)
+				}
+				schemaSQL = append(schemaSQL, objSQL)
+				return false, nil
+			})
+		if err != nil {
+			return err
+		}
+		for _, view := range views {
+			if err := sdb.DropView(view); err != nil {
+				return err
+			}
+		}
+
+		newTable := rebuildTablePrefix + table
+		if err := sdb.CreateTable(newTable + " " + newDef); err != nil {
+			return err
+		}
+		newColumns, exprs, err := sdb.rebuildColumns(table, newTable, columnMap)
+		if err != nil {
+			return err
+		}
+		if len(newColumns) > 0 {
+			err = sdb.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+				newTable, strings.Join(newColumns, ", "), strings.Join(exprs, ", "), table))
+			if err != nil {
+				return err
+			}
+		}
+		if err := sdb.Exec(fmt.Sprintf("DROP TABLE %s", table)); err != nil {
+			return err
+		}
+		if err := sdb.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", newTable, table)); err != nil {
+			return err
+		}
+		for _, objSQL := range schemaSQL {
+			if err := sdb.Exec(objSQL); err != nil {
+				return err
+			}
+		}
+
+		if foreignKeys {
+			violation, err := sdb.QueryExists("PRAGMA foreign_key_check")
+			if err != nil {
+				return err
+			}
+			if violation {
+				return fmt.Errorf("dberror: rebuilding %s violates foreign key constraints", table)
+			}
+		}
+		return nil
+	})
+}
+
+// rebuildColumns returns the columns of the new table to copy into, and the expressions
+// that compute their values from the old table.
+func (sdb *SQLDb) rebuildColumns(table, newTable string, columnMap map[string]string) ([]string, []string, error) {
+	var columns, exprs []string
+	if columnMap != nil {
+		for column, expr := range columnMap {
+			columns = append(columns, column)
+			exprs = append(exprs, expr)
+		}
+		return columns, exprs, nil
+	}
+
+	oldColumns, err := sdb.TableInfo(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	old := make(map[string]bool, len(oldColumns))
+	for _, col := range oldColumns {
+		old[strings.ToLower(col.Name)] = true
+	}
+	newColumns, err := sdb.TableInfo(newTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, col := range newColumns {
+		if old[strings.ToLower(col.Name)] {
+			columns = append(columns, col.Name)
+			exprs = append(exprs, col.Name)
+		}
+	}
+	return columns, exprs, nil
+}
@@ -0,0 +1,170 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func createRebuildTestTables(t *testing.T, sdb *SQLDb) {
+	err := sdb.ExecBatch([]BatchStmt{
+		{SQL: "CREATE TABLE parent (id INTEGER PRIMARY KEY)"},
+		{SQL: "CREATE TABLE testtable (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent (id), field1 TEXT, field2 TEXT)"},
+		{SQL: "CREATE INDEX test_idx ON testtable (field1)"},
+		{SQL: "CREATE TRIGGER test_trigger AFTER DELETE ON testtable BEGIN DELETE FROM parent WHERE id = old.parent_id; END"},
+		{SQL: "CREATE VIEW test_view AS SELECT id, field1 FROM testtable"},
+		{SQL: "INSERT INTO parent (id) VALUES (1)"},
+		{SQL: "INSERT INTO testtable (id, parent_id, field1, field2) VALUES (1, 1, 'a', '10')"},
+	})
+	if err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+}
+
+func TestRebuildTable_DropColumn(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb("file:" + testDbName + "?_foreign_keys=1")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	createRebuildTestTables(t, sdb)
+
+	if err := sdb.RebuildTable("testtable", "(id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent (id), field1 TEXT)", nil); err != nil {
+		t.Fatalf("RebuildTable error: %v", err)
+	}
+
+	exists, err := sdb.ColumnExists("testtable", "field2")
+	if err != nil || exists {
+		t.Errorf("Expected field2 to be dropped: %v, %v", exists, err)
+	}
+	var field1 string
+	if err := sdb.SingleQuery("SELECT field1 FROM test_view WHERE id = 1", &field1); err != nil || field1 != "a" {
+		t.Errorf("Expected view to return copied row: %v, %v", field1, err)
+	}
+	for _, name := range []string{"test_idx", "test_trigger"} {
+		exists, err := sdb.QueryExists("SELECT name FROM sqlite_master WHERE name = ?", name)
+		if err != nil || !exists {
+			t.Errorf("Expected %s to be recreated: %v, %v", name, exists, err)
+		}
+	}
+	var foreignKeys bool
+	if err := sdb.SingleQuery("PRAGMA foreign_keys", &foreignKeys); err != nil || !foreignKeys {
+		t.Errorf("Expected foreign keys to be restored: %v, %v", foreignKeys, err)
+	}
+}
+
+func TestRebuildTable_ColumnMap(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	createRebuildTestTables(t, sdb)
+
+	err := sdb.RebuildTable("testtable", "(id INTEGER PRIMARY KEY, parent_id INTEGER, field1 TEXT, amount INTEGER)",
+		map[string]string{"id": "id", "parent_id": "parent_id", "field1": "upper(field1)", "amount": "CAST(field2 AS INTEGER)"})
+	if err != nil {
+		t.Fatalf("RebuildTable error: %v", err)
+	}
+
+	var field1, amountType string
+	var amount int
+	if err := sdb.SingleQuery("SELECT field1, amount, typeof(amount) FROM testtable", &field1, &amount, &amountType); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if field1 != "A" || amount != 10 || amountType != "integer" {
+		t.Errorf("Expected (A, 10, integer), but was (%v, %v, %v)", field1, amount, amountType)
+	}
+}
+
+func TestRebuildTable_RollbackOnError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	createRebuildTestTables(t, sdb)
+
+	// The index on field1 cannot be recreated once field1 is dropped.
+	if err := sdb.RebuildTable("testtable", "(id INTEGER PRIMARY KEY, parent_id INTEGER, field2 TEXT)", nil); err == nil {
+		t.Fatal("RebuildTable did not return an error")
+	}
+	exists, err := sdb.ColumnExists("testtable", "field1")
+	if err != nil || !exists {
+		t.Errorf("Expected rebuild to be rolled back: %v, %v", exists, err)
+	}
+	if err := sdb.RebuildTable("notatable", "(id INTEGER)", nil); err == nil {
+		t.Error("RebuildTable did not return an error for a missing table")
+	}
+}
+
+func TestRebuildTable_ForeignKeyCascade(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb("file:" + testDbName + "?_foreign_keys=1")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	err = sdb.ExecBatch([]BatchStmt{
+		{SQL: "CREATE TABLE parent (id INTEGER PRIMARY KEY, name TEXT)"},
+		{SQL: "CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent (id) ON DELETE CASCADE)"},
+		{SQL: "INSERT INTO parent (id, name) VALUES (1, 'a')"},
+		{SQL: "INSERT INTO child (id, parent_id) VALUES (1, 1), (2, 1)"},
+	})
+	if err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+	countChildren := func() int {
+		var count int
+		if err := sdb.SingleQuery("SELECT COUNT(*) FROM child", &count); err != nil {
+			t.Fatalf("SingleQuery error: %v", err)
+		}
+		return count
+	}
+
+	// Inside a patch, foreign keys cannot be suspended, so the rebuild is refused.
+	err = sdb.PatchDb([]PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.RebuildTable("parent", "(id INTEGER PRIMARY KEY)", nil)
+		}},
+	})
+	if !errors.Is(err, ErrForeignKeysInTx) {
+		t.Errorf("Expected ErrForeignKeysInTx, but was %v", err)
+	}
+	if count := countChildren(); count != 2 {
+		t.Errorf("Expected the child rows to be kept, but there were %d", count)
+	}
+
+	if err := sdb.RebuildTable("parent", "(id INTEGER PRIMARY KEY)", nil); err != nil {
+		t.Fatalf("RebuildTable error: %v", err)
+	}
+	if count := countChildren(); count != 2 {
+		t.Errorf("Expected the child rows to be kept, but there were %d", count)
+	}
+	var foreignKeys bool
+	if err := sdb.SingleQuery("PRAGMA foreign_keys", &foreignKeys); err != nil || !foreignKeys {
+		t.Errorf("Expected foreign keys to be restored: %v, %v", foreignKeys, err)
+	}
+}
+
+func TestRebuildTable_InternalCopy(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	// A copy left behind by an interrupted rebuild is not an application table.
+	if err := sdb.CreateTable(rebuildTablePrefix + "testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if tables, err := sdb.ListTables(); err != nil || len(tables) != 1 || tables[0] != "testtable" {
+		t.Errorf("Expected only testtable, but was %v: %v", tables, err)
+	}
+}
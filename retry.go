@@ -0,0 +1,89 @@
+package sqldb
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// RetryPolicy - How to retry statements that fail because the database is busy or locked
+// by another connection. The zero value does not retry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles for each retry after that.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, if not zero.
+	MaxBackoff time.Duration
+	// Jitter randomizes each delay by up to this fraction of it, between 0 and 1,
+	// so that competing writers do not retry in lockstep.
+	Jitter float64
+}
+
+// WithRetry - Retry statements, queries, and commits that fail with SQLITE_BUSY or
+// SQLITE_LOCKED according to the policy. By default, these errors are returned immediately.
+// Inside a transaction, only commits are retried, and other errors are returned immediately.
+func WithRetry(policy RetryPolicy) Option {
+	return func(sdb *SQLDb) {
+		sdb.retry = policy
+	}
+}
+
+// RetryWith - Return a handle to the same database that retries with the policy instead.
+// Use it to override the retry policy for individual calls:
+//
+//	err := sdb.RetryWith(sqldb.RetryPolicy{}).Exec(stmt)
+//
+// The handle shares the connection pool, so closing either handle closes both, and
+// stops tracking the database for CloseAll.
+func (sdb *SQLDb) RetryWith(policy RetryPolicy) *SQLDb {
+	retrying := *sdb
+	retrying.retry = policy
+	return &retrying
+}
+
+// retryPolicy returns the policy for running the statement. Inside a transaction, only a
+// commit is retried. Other statements fail with SQLITE_BUSY there when the transaction cannot
+// take the write lock, which retrying the statement cannot fix, since the whole transaction
+// has to be started again.
+func (sdb *SQLDb) retryPolicy(stmt string) RetryPolicy {
+	if sdb.activity.inTransaction(sdb.txn) && !strings.EqualFold(stmt, "COMMIT") {
+		return RetryPolicy{}
+	}
+	return sdb.retry
+}
+
+// Do - Call fn, calling it again after a backoff delay for as long as it fails with
+// SQLITE_BUSY or SQLITE_LOCKED, up to the maximum attempts. Returns the last error.
+func (rp RetryPolicy) Do(fn func() error) error {
+	err := fn()
+	delay := rp.Backoff
+	for attempt := 1; attempt < rp.MaxAttempts && isBusy(err); attempt++ {
+		time.Sleep(rp.jitter(delay))
+		delay *= 2
+		if rp.MaxBackoff > 0 && delay > rp.MaxBackoff {
+			delay = rp.MaxBackoff
+		}
+		err = fn()
+	}
+	return err
+}
+
+func (rp RetryPolicy) jitter(delay time.Duration) time.Duration {
+	if rp.Jitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration(rp.Jitter*(2*rand.Float64()-1)*float64(delay))
+}
+
+// isBusy reports whether the error is a transient lock conflict with another connection.
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
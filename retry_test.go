@@ -0,0 +1,149 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Fail immediately when the database is locked, rather than waiting in the driver.
+const testNoWaitDbName = "file:" + testDbName + "?_busy_timeout=0"
+
+func TestRetryPolicy_Do(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Jitter: 0.5}
+
+	calls := 0
+	err := policy.Do(func() error {
+		calls++
+		return busy
+	})
+	if !isBusy(err) || calls != 3 {
+		t.Errorf("Expected 3 busy attempts, but was %v: %v", calls, err)
+	}
+
+	calls = 0
+	err = policy.Do(func() error {
+		calls++
+		if calls < 2 {
+			return busy
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expected success on attempt 2, but was %v: %v", calls, err)
+	}
+
+	calls = 0
+	other := errors.New("not busy")
+	if err := policy.Do(func() error {
+		calls++
+		return other
+	}); err != other || calls != 1 {
+		t.Errorf("Expected no retry of other errors, but was %v: %v", calls, err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	locker, err := OpenDb(testNoWaitDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &locker)
+	if err := locker.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	sdb, err := OpenDb(testNoWaitDbName, WithRetry(RetryPolicy{MaxAttempts: 100, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if err := locker.BeginImmediateTrans(); err != nil {
+		t.Fatalf("BeginImmediateTrans error: %v", err)
+	}
+	// Without retries, the write fails while the other handle holds the lock.
+	if err := sdb.RetryWith(RetryPolicy{}).Exec("INSERT INTO testtable (id) VALUES (1)"); err == nil {
+		t.Error("Expected busy error without retries")
+	}
+
+	done := make(chan error, 1)
+	time.AfterFunc(50*time.Millisecond, func() {
+		done <- locker.CommitTrans()
+	})
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (2)"); err != nil {
+		t.Errorf("Exec with retries error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("CommitTrans error: %v", err)
+	}
+
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 1 {
+		t.Errorf("Expected 1 row, but was %v: %v", count, err)
+	}
+}
+
+func TestWithRetry_InTransaction(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	locker, err := OpenDb(testNoWaitDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &locker)
+	if err := locker.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	sdb, err := OpenDb(testNoWaitDbName, WithRetry(RetryPolicy{MaxAttempts: 100, Backoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	if err := locker.BeginImmediateTrans(); err != nil {
+		t.Fatalf("BeginImmediateTrans error: %v", err)
+	}
+	// The transaction cannot take the write lock by retrying, so the error is returned at once.
+	start := time.Now()
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); !isBusy(err) {
+		t.Errorf("Expected busy error, but was %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected no retries inside a transaction, but took %v", elapsed)
+	}
+	if err := sdb.RollbackTrans(); err != nil {
+		t.Errorf("RollbackTrans error: %v", err)
+	}
+	if err := locker.CommitTrans(); err != nil {
+		t.Errorf("CommitTrans error: %v", err)
+	}
+}
+
+func TestRetryWith_Close(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	// Closing the handle made by RetryWith closes the database, which stops it being tracked.
+	if err := sdb.RetryWith(RetryPolicy{}).Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	openDbs.Lock()
+	_, tracked := openDbs.dbs[sdb.activity]
+	openDbs.Unlock()
+	if tracked {
+		t.Error("Expected the closed database to not be tracked for CloseAll")
+	}
+}
@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// QueryMaps - Query the database with the bound arguments, and return each row as a map
+// from column name to value, for results whose columns are not known until run time.
+// Values are as returned by the driver: int64, float64, string, []byte, time.Time, or nil.
+func (sdb *SQLDb) QueryMaps(stmt string, args ...interface{}) (_ []map[string]interface{}, err error) {
+	defer sdb.recoverPanic(&err)
+	if args, err = convertArgs(args); err != nil {
+		return nil, err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	var maps []map[string]interface{}
+	for rows.Next() {
+		m, err := scanMap(rows)
+		if err != nil {
+			return nil, fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+		}
+		maps = append(maps, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return maps, nil
+}
+
+// QueryMap - Query the database with the bound arguments, and return the first row as a map
+// from column name to value, like QueryMaps. Returns an error wrapping ErrNoRows if no rows match.
+func (sdb *SQLDb) QueryMap(stmt string, args ...interface{}) (_ map[string]interface{}, err error) {
+	defer sdb.recoverPanic(&err)
+	if args, err = convertArgs(args); err != nil {
+		return nil, err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	if rows.Next() {
+		m, err := scanMap(rows)
+		if err != nil {
+			return nil, fmt.Errorf("dberror: scanning %s: %v", stmt, err)
+		}
+		return m, nil
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return nil, fmt.Errorf("dberror: could not retrieve query value for %s: %w", stmt, ErrNoRows)
+}
+
+// scanMap scans the current row into a map from column name to value.
+func scanMap(rows *sql.Rows) (map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		m[column] = values[i]
+	}
+	return m, nil
+}
@@ -0,0 +1,48 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryMaps(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, name TEXT, score REAL, data BLOB)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable VALUES (1, 'a', 1.5, x'01'), (2, NULL, 2.5, NULL)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	maps, err := sdb.QueryMaps("SELECT id, name, score, data FROM testtable WHERE id > ? ORDER BY id", 0)
+	if err != nil {
+		t.Fatalf("QueryMaps error: %v", err)
+	}
+	expected := []map[string]interface{}{
+		{"id": int64(1), "name": "a", "score": 1.5, "data": []byte{1}},
+		{"id": int64(2), "name": nil, "score": 2.5, "data": nil},
+	}
+	if !reflect.DeepEqual(maps, expected) {
+		t.Errorf("Expected %v, but was %v", expected, maps)
+	}
+
+	m, err := sdb.QueryMap("PRAGMA table_info(testtable)")
+	if err != nil {
+		t.Fatalf("QueryMap error: %v", err)
+	}
+	if m["name"] != "id" || m["type"] != "INTEGER" {
+		t.Errorf("Expected the id column info, but was %v", m)
+	}
+
+	if _, err := sdb.QueryMap("SELECT id FROM testtable WHERE id > 2"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+	if _, err := sdb.QueryMaps("SELECT id FROM notatable"); err == nil {
+		t.Error("QueryMaps of a missing table did not return an error")
+	}
+}
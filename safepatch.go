@@ -0,0 +1,270 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// The suffix of the working copy of the database file that SafePatchDb patches.
+const safePatchSuffix = ".patching"
+
+// ErrNotFileDb is returned by operations that require a database file, such as SafePatchDb,
+// when the database is in-memory.
+var ErrNotFileDb = errors.New("dberror: database is not a file")
+
+// ErrDbInUse is returned by SafePatchDb when the database is in use by the handle, or by
+// another connection, so its file cannot be swapped.
+var ErrDbInUse = errors.New("dberror: database is in use")
+
+// OpenAndSafePatchDb - Open a database, and safe patch it if necessary.
+// See SafePatchDb for how the patches are applied.
+func OpenAndSafePatchDb(dbFilename string, patchFuncs []PatchFuncType, verify func(sdb *SQLDb) error, opts ...Option) (*SQLDb, error) {
+	sdb, err := OpenDb(dbFilename, opts...)
+	if err != nil {
+		return sdb, err
+	}
+	if err := sdb.SafePatchDb(patchFuncs, verify); err != nil {
+		return sdb, err
+	}
+	return sdb, nil
+}
+
+// SafePatchDb - Patch a copy of the database file, and swap it in place of the original only
+// once every patch has been applied and the copy has been verified. The copy is verified with
+// an integrity check and then with the optional verify function. The swap is an atomic file
+// rename, so a crash at any point leaves either the original or the fully patched database.
+// The database handle is reopened on the patched file.
+// The handle must be idle: it may not be used by other goroutines while it is safe patched,
+// and returns ErrDbInUse if it has a transaction open or a connection in use. The swap also
+// returns ErrDbInUse if the database is open on any other connection in WAL mode, or is in
+// use on any other connection in rollback journal mode. Other handles and processes must
+// close the database before it is safe patched, since they would not see the swapped file.
+func (sdb *SQLDb) SafePatchDb(patchFuncs []PatchFuncType, verify func(sdb *SQLDb) error) error {
+	path := dbFilePath(sdb.filename)
+	if path == "" {
+		return ErrNotFileDb
+	}
+	if sdb.readOnly {
+		return ErrReadOnly
+	}
+	if err := sdb.checkIdle(); err != nil {
+		return err
+	}
+	pending, err := sdb.pendingPatches(internalPatchDbFuncs)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		if pending, err = sdb.pendingPatches(patchFuncs); err != nil {
+			return err
+		}
+	}
+	if !pending {
+		return nil
+	}
+
+	copyPath := path + safePatchSuffix
+	// Remove any working copy left behind by an interrupted patch.
+	removeDbFiles(copyPath)
+	if err := sdb.VacuumInto(copyPath); err != nil {
+		return fmt.Errorf("could not copy database for patching: %v", err)
+	}
+	if err := sdb.patchCopy(copyPath, patchFuncs, verify); err != nil {
+		removeDbFiles(copyPath)
+		return err
+	}
+
+	if err := sdb.checkIdle(); err != nil {
+		removeDbFiles(copyPath)
+		return err
+	}
+	// The database is reopened whether or not the swap succeeds, and an error reopening it
+	// is reported along with any other, since the handle is left closed.
+	if err := sdb.closeDB(); err != nil {
+		removeDbFiles(copyPath)
+		return errors.Join(err, sdb.open())
+	}
+	if err := sdb.swapDbFile(path, copyPath); err != nil {
+		removeDbFiles(copyPath)
+		return errors.Join(err, sdb.open())
+	}
+	return sdb.open()
+}
+
+// checkIdle returns ErrDbInUse unless the handle has no open transaction and no connection in use,
+// other than the connection held by the single writer.
+func (sdb *SQLDb) checkIdle() error {
+	inUse := sdb.DB.Stats().InUse
+	if sdb.writer != nil {
+		inUse--
+	}
+	if sdb.conn != nil || !sdb.activity.idle() || inUse > 0 {
+		return ErrDbInUse
+	}
+	return nil
+}
+
+// swapDbFile replaces the database file with the patched copy. It holds an exclusive lock on the
+// original while it is replaced, so no other connection can be using it.
+func (sdb *SQLDb) swapDbFile(path, copyPath string) error {
+	conn, err := (&sqlite3.SQLiteDriver{}).Open(sdb.filename)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	lock := conn.(*sqlite3.SQLiteConn)
+	if err := sdb.setEncryptionKey(lock); err != nil {
+		return err
+	}
+	var prevMode string
+	if err := queryPragma(lock, "PRAGMA journal_mode", &prevMode); err != nil {
+		return err
+	}
+	swapped := false
+	defer func() {
+		// Leave the original as it was, if it is not swapped.
+		if !swapped && strings.EqualFold(prevMode, "wal") {
+			lock.Exec("ROLLBACK", nil)
+			lock.Exec("PRAGMA journal_mode = WAL", nil)
+		}
+	}()
+	// Move the contents of the WAL into the original, which must be done before the WAL can be removed.
+	// Leaving WAL mode needs the only connection to the database, and the exclusive transaction
+	// keeps any other connection from starting to use it until the swap is done.
+	var busy int64
+	if err := queryPragma(lock, "PRAGMA wal_checkpoint(TRUNCATE)", &busy); err != nil {
+		return lockError(err)
+	}
+	var mode string
+	if err := queryPragma(lock, "PRAGMA journal_mode = DELETE", &mode); err != nil {
+		return lockError(err)
+	}
+	if busy != 0 || !strings.EqualFold(mode, "delete") {
+		return ErrDbInUse
+	}
+	if _, err := lock.Exec("BEGIN EXCLUSIVE", nil); err != nil {
+		return lockError(err)
+	}
+	// The WAL of the original, if any, must not be replayed into the patched file.
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	if err := syncFile(copyPath); err != nil {
+		return fmt.Errorf("could not sync patched database: %v", err)
+	}
+	if err := os.Rename(copyPath, path); err != nil {
+		return fmt.Errorf("could not swap patched database: %v", err)
+	}
+	swapped = true
+	// Sync the directory, so the rename survives a crash.
+	if err := syncFile(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("could not sync patched database: %v", err)
+	}
+	return nil
+}
+
+// lockError returns ErrDbInUse for an error taking a lock that another connection holds.
+func lockError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) {
+		return fmt.Errorf("%w: %v", ErrDbInUse, err)
+	}
+	return err
+}
+
+// queryPragma runs the pragma on the connection, and scans the first column of its result into dest.
+func queryPragma(conn *sqlite3.SQLiteConn, pragma string, dest interface{}) error {
+	rows, err := conn.Query(pragma, nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	values := make([]driver.Value, len(rows.Columns()))
+	if err := rows.Next(values); err != nil {
+		return err
+	}
+	switch d := dest.(type) {
+	case *int64:
+		*d, _ = values[0].(int64)
+	case *string:
+		*d, _ = values[0].(string)
+	}
+	return nil
+}
+
+// syncFile flushes the file or directory to stable storage.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// patchCopy patches and verifies the working copy of the database.
+func (sdb *SQLDb) patchCopy(copyPath string, patchFuncs []PatchFuncType, verify func(sdb *SQLDb) error) error {
+	copyDb, err := OpenDb(replaceDbFilePath(sdb.filename, copyPath), sdb.opts...)
+	if err != nil {
+		copyDb.Close()
+		return err
+	}
+	defer copyDb.Close()
+	if err := copyDb.PatchDb(patchFuncs); err != nil {
+		return err
+	}
+	problems, err := copyDb.IntegrityCheck()
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("patched database failed integrity check: %s", strings.Join(problems, "; "))
+	}
+	if verify != nil {
+		if err := copyDb.call(func() error { return verify(copyDb) }); err != nil {
+			return fmt.Errorf("patched database failed verification: %w", err)
+		}
+	}
+	return nil
+}
+
+// pendingPatches reports whether any of the patches have not been applied.
+func (sdb *SQLDb) pendingPatches(patchFuncs []PatchFuncType) (bool, error) {
+	for _, patch := range patchFuncs {
+		patched, err := sdb.patched(patch.PatchID)
+		if err != nil || !patched {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// dbFilePath returns the file path of the database DSN, or an empty string for
+// in-memory databases.
+func dbFilePath(dbFilename string) string {
+	path := strings.TrimPrefix(dbFilename, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	if path == ":memory:" || strings.Contains(dbFilename, "mode=memory") {
+		return ""
+	}
+	return path
+}
+
+// replaceDbFilePath returns the database DSN with its file path replaced, keeping any parameters.
+func replaceDbFilePath(dbFilename, path string) string {
+	return strings.Replace(dbFilename, dbFilePath(dbFilename), path, 1)
+}
+
+// removeDbFiles removes the database file along with its journal files.
+func removeDbFiles(path string) {
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		os.Remove(path + suffix)
+	}
+}
@@ -0,0 +1,163 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+
+	gocommon "github.com/semog/go-common"
+)
+
+var testSafePatchFuncs = []PatchFuncType{
+	{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+		return sdb.CreateTable("testtable (id INTEGER)")
+	}},
+}
+
+func TestSafePatchDb(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testDbName, testSafePatchFuncs)
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+
+	verifyCalled := false
+	patchFuncs := append(testSafePatchFuncs, PatchFuncType{PatchID: 2, PatchFunc: func(sdb *SQLDb) error {
+		return sdb.Exec("ALTER TABLE testtable ADD COLUMN field1 TEXT DEFAULT 'patched'")
+	}})
+	err = sdb.SafePatchDb(patchFuncs, func(copyDb *SQLDb) error {
+		verifyCalled = true
+		var field1 string
+		return copyDb.SingleQuery("SELECT field1 FROM testtable", &field1)
+	})
+	if err != nil {
+		t.Fatalf("SafePatchDb error: %v", err)
+	}
+	if !verifyCalled {
+		t.Error("Did not call verify function")
+	}
+	if gocommon.FileExists(testDbName + safePatchSuffix) {
+		t.Error("Working copy was not swapped in")
+	}
+
+	// The reopened handle sees the patched database, with the original data.
+	var id int
+	var field1 string
+	if err := sdb.SingleQuery("SELECT id, field1 FROM testtable", &id, &field1); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if id != 1 || field1 != "patched" {
+		t.Errorf("Expected (1, patched), but was (%v, %v)", id, field1)
+	}
+}
+
+func TestSafePatchDb_VerifyError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	verifyErr := errors.New("verify failure")
+	sdb, err := OpenAndSafePatchDb(testDbName, testSafePatchFuncs, func(copyDb *SQLDb) error {
+		return verifyErr
+	})
+	defer closeDb(t, &sdb)
+	if !errors.Is(err, verifyErr) {
+		t.Errorf("Expected verify error, but was %v", err)
+	}
+	if gocommon.FileExists(testDbName + safePatchSuffix) {
+		t.Error("Working copy was not removed")
+	}
+
+	// The original database was left unpatched.
+	patched, err := sdb.patched(0)
+	if err != nil || patched {
+		t.Errorf("Expected original database to be unpatched: %v, %v", patched, err)
+	}
+}
+
+func TestSafePatchDb_NothingPending(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testDbName, testSafePatchFuncs)
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	err = sdb.SafePatchDb(testSafePatchFuncs, func(copyDb *SQLDb) error {
+		t.Error("Verify called with no pending patches")
+		return nil
+	})
+	if err != nil {
+		t.Errorf("SafePatchDb error: %v", err)
+	}
+}
+
+func TestSafePatchDb_MemoryDb(t *testing.T) {
+	sdb, err := OpenDb(":memory:")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if err := sdb.SafePatchDb(testSafePatchFuncs, nil); !errors.Is(err, ErrNotFileDb) {
+		t.Errorf("Expected ErrNotFileDb, but was %v", err)
+	}
+}
+
+func TestSafePatchDb_InUse(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb("file:"+testDbName+"?_journal_mode=WAL", testSafePatchFuncs)
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+	patchFuncs := append(testSafePatchFuncs, PatchFuncType{PatchID: 2, PatchFunc: func(sdb *SQLDb) error {
+		return sdb.Exec("ALTER TABLE testtable ADD COLUMN field1 TEXT")
+	}})
+
+	// The handle is not idle while it has a transaction open.
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	if err := sdb.SafePatchDb(patchFuncs, nil); !errors.Is(err, ErrDbInUse) {
+		t.Errorf("Expected ErrDbInUse in a transaction, but was %v", err)
+	}
+	if err := sdb.RollbackTrans(); err != nil {
+		t.Fatalf("RollbackTrans error: %v", err)
+	}
+
+	// Another handle has the database open.
+	other, err := OpenDb("file:" + testDbName + "?_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	if err := other.Ping(); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+	if err := sdb.SafePatchDb(patchFuncs, nil); !errors.Is(err, ErrDbInUse) {
+		t.Errorf("Expected ErrDbInUse with another connection open, but was %v", err)
+	}
+	if gocommon.FileExists(testDbName + safePatchSuffix) {
+		t.Error("Expected the working copy to be removed")
+	}
+	// The handle was reopened after the failed swap.
+	if err := sdb.Ping(); err != nil {
+		t.Errorf("Ping after a failed swap error: %v", err)
+	}
+	closeDb(t, &other)
+
+	if err := sdb.SafePatchDb(patchFuncs, nil); err != nil {
+		t.Fatalf("SafePatchDb error: %v", err)
+	}
+	if exists, err := sdb.ColumnExists("testtable", "field1"); err != nil || !exists {
+		t.Errorf("Expected the patched column: %v, %v", exists, err)
+	}
+}
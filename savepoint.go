@@ -0,0 +1,39 @@
+package sqldb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// NestedTxn - Execute the database function inside a save point with a generated name.
+// Nested calls get distinct names (sp_1, sp_2, ...), so callers need not invent unique
+// save point names. Outside of a transaction, the save point begins one. If the function
+// panics, the save point is rolled back before the panic continues.
+func (sdb *SQLDb) NestedTxn(fn func() error) error {
+	name := sdb.savePoints.push()
+	defer sdb.savePoints.pop()
+	return sdb.ExecWithSavePoint(name, fn)
+}
+
+// savePointStack generates the names of the nested save points created by NestedTxn.
+type savePointStack struct {
+	mu    sync.Mutex
+	depth int
+}
+
+// push returns the name of a new innermost save point.
+func (s *savePointStack) push() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depth++
+	return fmt.Sprintf("sp_%d", s.depth)
+}
+
+// pop removes the innermost save point, once it has been released or rolled back.
+func (s *savePointStack) pop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.depth > 0 {
+		s.depth--
+	}
+}
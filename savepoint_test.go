@@ -0,0 +1,70 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNestedTxn(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	innerErr := errors.New("inner failed")
+	var names []string
+	err := sdb.NestedTxn(func() error {
+		names = append(names, currentSavePoint(sdb))
+		if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			return err
+		}
+		// The failed inner save point only rolls back its own changes.
+		if err := sdb.NestedTxn(func() error {
+			names = append(names, currentSavePoint(sdb))
+			if err := sdb.Exec("INSERT INTO testtable (id) VALUES (2)"); err != nil {
+				return err
+			}
+			return innerErr
+		}); err != innerErr {
+			t.Errorf("Expected inner error, but was %v", err)
+		}
+		return sdb.NestedTxn(func() error {
+			names = append(names, currentSavePoint(sdb))
+			return sdb.Exec("INSERT INTO testtable (id) VALUES (3)")
+		})
+	})
+	if err != nil {
+		t.Fatalf("NestedTxn error: %v", err)
+	}
+
+	if len(names) != 3 || names[0] != "sp_1" || names[1] != "sp_2" || names[2] != "sp_2" {
+		t.Errorf("Expected save points sp_1, sp_2, sp_2, but was %v", names)
+	}
+	var ids []int
+	if err := sdb.MultiQuery("SELECT id FROM testtable ORDER BY id", func(rows *sql.Rows) error {
+		var id int
+		err := rows.Scan(&id)
+		ids = append(ids, id)
+		return err
+	}); err != nil {
+		t.Fatalf("MultiQuery error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("Expected ids 1 and 3, but was %v", ids)
+	}
+	if !sdb.activity.idle() {
+		t.Error("Expected no open transaction")
+	}
+}
+
+func currentSavePoint(sdb *SQLDb) string {
+	sdb.savePoints.mu.Lock()
+	defer sdb.savePoints.mu.Unlock()
+	return fmt.Sprintf("sp_%d", sdb.savePoints.depth)
+}
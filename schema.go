@@ -0,0 +1,300 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ColumnInfo - Definition of a table column, as reported by PRAGMA table_info.
+type ColumnInfo struct {
+	// CID is the position of the column in the table, starting at zero.
+	CID     int
+	Name    string
+	Type    string
+	NotNull bool
+	// Default is the default value expression of the column, if any.
+	Default sql.NullString
+	// PrimaryKey is the position of the column within the primary key, starting at one,
+	// or zero if the column is not part of the primary key.
+	PrimaryKey int
+}
+
+// TableExists - Report whether the table exists.
+func (sdb *SQLDb) TableExists(name string) (bool, error) {
+	return sdb.QueryExists("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", name)
+}
+
+// ColumnExists - Report whether the column exists in the table.
+func (sdb *SQLDb) ColumnExists(table, column string) (bool, error) {
+	return sdb.QueryExists("SELECT name FROM pragma_table_info(?) WHERE name = ?", table, column)
+}
+
+// IndexExists - Report whether the index exists.
+func (sdb *SQLDb) IndexExists(name string) (bool, error) {
+	return sdb.QueryExists("SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?", name)
+}
+
+// TableInfo - Return the column definitions of the table, in column order.
+// A table that does not exist has no columns.
+func (sdb *SQLDb) TableInfo(table string) ([]ColumnInfo, error) {
+	rows, err := sdb.query("SELECT cid, name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid", table)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	var columns []ColumnInfo
+	for rows.Next() {
+		var col ColumnInfo
+		if err := rows.Scan(&col.CID, &col.Name, &col.Type, &col.NotNull, &col.Default, &col.PrimaryKey); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// ListTables - Return the names of the tables in the database, in name order.
+// SQLite's own internal tables, and the tables the package creates, such as the version table
+// and the key-value store, are not included.
+func (sdb *SQLDb) ListTables() ([]string, error) {
+	names, err := sdb.queryNames("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	tables := names[:0]
+	for _, name := range names {
+		if !isInternalObject("table", name) {
+			tables = append(tables, name)
+		}
+	}
+	return tables, nil
+}
+
+// queryNames returns the single text column of every row returned by the query.
+func (sdb *SQLDb) queryNames(stmt string, args ...interface{}) ([]string, error) {
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// SchemaChangeKind - The kind of difference between the schemas of two databases.
+type SchemaChangeKind int
+
+const (
+	// SchemaAdded is an object that only the other database has.
+	SchemaAdded SchemaChangeKind = iota
+	// SchemaRemoved is an object that only this database has.
+	SchemaRemoved
+	// SchemaChanged is an object that both databases have, with different definitions.
+	SchemaChanged
+)
+
+var schemaChangeKindNames = []string{
+	"added",
+	"removed",
+	"changed",
+}
+
+func (k SchemaChangeKind) String() string {
+	if k >= 0 && int(k) < len(schemaChangeKindNames) {
+		return schemaChangeKindNames[k]
+	}
+	return fmt.Sprintf("SchemaChangeKind(%d)", int(k))
+}
+
+// SchemaChange - A difference between the schemas of two databases.
+type SchemaChange struct {
+	Kind SchemaChangeKind
+	// Type is the type of the schema object: table, index, view, or trigger.
+	Type string
+	Name string
+	// Old and New are the normalized definitions in this database and in the other database,
+	// which are empty for objects that are added or removed.
+	Old string
+	New string
+}
+
+func (sc SchemaChange) String() string {
+	return fmt.Sprintf("%s %s %s", sc.Kind, sc.Type, sc.Name)
+}
+
+// schemaObject is a table, index, view, or trigger, with its normalized definition.
+type schemaObject struct {
+	objType string
+	name    string
+	sql     string
+}
+
+// DumpSchema - Return the CREATE statements of the tables, indexes, views, and triggers of the
+// database, one per line, ordered by type and name. Whitespace in the statements is normalized,
+// so databases with the same schema dump the same text however their statements were written.
+// SQLite's own objects and the tables created by the internal patches are not included.
+func (sdb *SQLDb) DumpSchema() (string, error) {
+	objects, err := sdb.schemaObjects()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, obj := range objects {
+		sb.WriteString(obj.sql)
+		sb.WriteString(";\n")
+	}
+	return sb.String(), nil
+}
+
+// DiffSchema - Compare the schema of the database to the schema of the other database, as
+// dumped by DumpSchema, and return the changes that turn this schema into the other, ordered
+// by type and name. For example, compare a patched database to a freshly created one.
+func (sdb *SQLDb) DiffSchema(other *SQLDb) ([]SchemaChange, error) {
+	objects, err := sdb.schemaObjects()
+	if err != nil {
+		return nil, err
+	}
+	otherObjects, err := other.schemaObjects()
+	if err != nil {
+		return nil, err
+	}
+	key := func(obj schemaObject) string {
+		return obj.objType + " " + strings.ToLower(obj.name)
+	}
+	otherByKey := make(map[string]schemaObject, len(otherObjects))
+	for _, obj := range otherObjects {
+		otherByKey[key(obj)] = obj
+	}
+
+	var changes []SchemaChange
+	seen := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		seen[key(obj)] = true
+		otherObj, ok := otherByKey[key(obj)]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{Kind: SchemaRemoved, Type: obj.objType, Name: obj.name, Old: obj.sql})
+		case otherObj.sql != obj.sql:
+			changes = append(changes, SchemaChange{Kind: SchemaChanged, Type: obj.objType, Name: obj.name,
+				Old: obj.sql, New: otherObj.sql})
+		}
+	}
+	for _, obj := range otherObjects {
+		if !seen[key(obj)] {
+			changes = append(changes, SchemaChange{Kind: SchemaAdded, Type: obj.objType, Name: obj.name, New: obj.sql})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return schemaTypeOrder(changes[i].Type) < schemaTypeOrder(changes[j].Type)
+		}
+		return strings.ToLower(changes[i].Name) < strings.ToLower(changes[j].Name)
+	})
+	return changes, nil
+}
+
+// schemaObjects returns the schema objects of the database, ordered by type and name.
+func (sdb *SQLDb) schemaObjects() ([]schemaObject, error) {
+	var objects []schemaObject
+	err := sdb.MultiQuery(`SELECT type, name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'`, func(rows *sql.Rows) error {
+		var obj schemaObject
+		if err := rows.Scan(&obj.objType, &obj.name, &obj.sql); err != nil {
+			return err
+		}
+		if !isInternalObject(obj.objType, obj.name) {
+			obj.sql = normalizeSQL(obj.sql)
+			objects = append(objects, obj)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].objType != objects[j].objType {
+			return schemaTypeOrder(objects[i].objType) < schemaTypeOrder(objects[j].objType)
+		}
+		return strings.ToLower(objects[i].name) < strings.ToLower(objects[j].name)
+	})
+	return objects, nil
+}
+
+// schemaTypeOrder orders schema objects so that each is created after the objects it uses.
+func schemaTypeOrder(objType string) int {
+	switch objType {
+	case "table":
+		return 0
+	case "index":
+		return 1
+	case "view":
+		return 2
+	}
+	return 3
+}
+
+// normalizeSQL removes comments, collapses each run of whitespace outside of quotes to a
+// single space, and removes the whitespace inside parentheses and before commas, while keeping
+// a single space after each comma.
+func normalizeSQL(stmt string) string {
+	var sb strings.Builder
+	pendingSpace := false
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		if quote != 0 {
+			sb.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		// Comments are whitespace, and a line comment runs to the end of the line.
+		if strings.HasPrefix(stmt[i:], "--") {
+			if end := strings.IndexByte(stmt[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(stmt)
+			}
+			pendingSpace = true
+			continue
+		}
+		if strings.HasPrefix(stmt[i:], "/*") {
+			if end := strings.Index(stmt[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(stmt)
+			}
+			pendingSpace = true
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			pendingSpace = true
+			continue
+		case ')', ',':
+			pendingSpace = false
+		}
+		if pendingSpace && sb.Len() > 0 && !strings.HasSuffix(sb.String(), "(") {
+			sb.WriteByte(' ')
+		}
+		pendingSpace = c == ','
+		sb.WriteByte(c)
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			quote = ']'
+		}
+	}
+	return strings.TrimSuffix(sb.String(), ";")
+}
@@ -0,0 +1,175 @@
+package sqldb
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaIntrospection(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, field1 TEXT NOT NULL DEFAULT 'x', field2 REAL)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("test_idx ON testtable (field1)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+
+	checkExists := func(desc string, exists bool, err error, expected bool) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("%s error: %v", desc, err)
+		} else if exists != expected {
+			t.Errorf("%s: expected %v, but was %v", desc, expected, exists)
+		}
+	}
+	exists, err := sdb.TableExists("testtable")
+	checkExists("TableExists", exists, err, true)
+	exists, err = sdb.TableExists("notatable")
+	checkExists("TableExists missing", exists, err, false)
+	exists, err = sdb.ColumnExists("testtable", "field2")
+	checkExists("ColumnExists", exists, err, true)
+	exists, err = sdb.ColumnExists("testtable", "field3")
+	checkExists("ColumnExists missing", exists, err, false)
+	exists, err = sdb.IndexExists("test_idx")
+	checkExists("IndexExists", exists, err, true)
+	exists, err = sdb.IndexExists("notanindex")
+	checkExists("IndexExists missing", exists, err, false)
+
+	columns, err := sdb.TableInfo("testtable")
+	if err != nil {
+		t.Fatalf("TableInfo error: %v", err)
+	}
+	if len(columns) != 3 {
+		t.Fatalf("Expected 3 columns, but was %v", columns)
+	}
+	if columns[0].Name != "id" || columns[0].PrimaryKey != 1 {
+		t.Errorf("Expected id primary key column, but was %v", columns[0])
+	}
+	if columns[1].Type != "TEXT" || !columns[1].NotNull || columns[1].Default.String != "'x'" {
+		t.Errorf("Expected field1 TEXT NOT NULL DEFAULT 'x', but was %v", columns[1])
+	}
+	if columns[2].NotNull || columns[2].Default.Valid {
+		t.Errorf("Expected nullable field2 with no default, but was %v", columns[2])
+	}
+
+	tables, err := sdb.ListTables()
+	if err != nil {
+		t.Fatalf("ListTables error: %v", err)
+	}
+	expected := []string{"testtable"}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Errorf("Expected tables %v, but was %v", expected, tables)
+	}
+}
+
+func TestDumpAndDiffSchema(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testVacuumDbName)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY,\n\tname   TEXT  DEFAULT 'a  b' )"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("test_idx ON testtable (name)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+
+	dump, err := sdb.DumpSchema()
+	if err != nil {
+		t.Fatalf("DumpSchema error: %v", err)
+	}
+	expected := "CREATE TABLE testtable (id INTEGER PRIMARY KEY, name TEXT DEFAULT 'a  b');\n" +
+		"CREATE INDEX test_idx ON testtable (name);\n"
+	if dump != expected {
+		t.Errorf("Expected dump %q, but was %q", expected, dump)
+	}
+
+	other, err := OpenDb(testVacuumDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &other)
+	if err := other.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT DEFAULT 'a  b')"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if changes, err := sdb.DiffSchema(other); err != nil || len(changes) != 1 ||
+		changes[0].Kind != SchemaRemoved || changes[0].Name != "test_idx" {
+		t.Errorf("Expected the index removed, but was %v: %v", changes, err)
+	}
+
+	if err := other.CreateIndex("test_idx ON testtable (id)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+	if err := other.CreateView("test_view AS SELECT id FROM testtable"); err != nil {
+		t.Fatalf("CreateView error: %v", err)
+	}
+	changes, err := sdb.DiffSchema(other)
+	if err != nil {
+		t.Fatalf("DiffSchema error: %v", err)
+	}
+	if len(changes) != 2 || changes[0].String() != "changed index test_idx" || changes[1].String() != "added view test_view" {
+		t.Errorf("Expected the index changed and the view added, but was %v", changes)
+	}
+	if changes[0].New != "CREATE INDEX test_idx ON testtable (id)" {
+		t.Errorf("Expected the new index definition, but was %q", changes[0].New)
+	}
+}
+
+func TestDumpSchema_UserObjects(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	// The package's own tables have reserved names, so an application may name a table seeds.
+	if err := sdb.CreateTable("seeds (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("seeds_name ON seeds (name)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO seeds (id) VALUES (1)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	// Streaming a blob creates internal triggers on the application table.
+	if _, err := sdb.WriteBlob("seeds", "name", 1, strings.NewReader("a")); err != nil {
+		t.Fatalf("WriteBlob error: %v", err)
+	}
+
+	dump, err := sdb.DumpSchema()
+	if err != nil {
+		t.Fatalf("DumpSchema error: %v", err)
+	}
+	if !strings.Contains(dump, "CREATE INDEX seeds_name ON seeds (name);\n") {
+		t.Errorf("Expected the index on the application table, but was %q", dump)
+	}
+	if strings.Contains(dump, internalPrefix) {
+		t.Errorf("Expected no internal objects, but was %q", dump)
+	}
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected string
+	}{
+		{"CREATE TABLE t (\n\tid  INTEGER ,\n\tname TEXT\n)", "CREATE TABLE t (id INTEGER, name TEXT)"},
+		{"CREATE TABLE t (id INTEGER, -- the key\n\tname TEXT -- it's the name\n)", "CREATE TABLE t (id INTEGER, name TEXT)"},
+		{"CREATE TABLE t (id INTEGER /* the 'key' */, name TEXT)", "CREATE TABLE t (id INTEGER, name TEXT)"},
+		{"CREATE TABLE t (name TEXT DEFAULT '-- not a comment')", "CREATE TABLE t (name TEXT DEFAULT '-- not a comment')"},
+		{"CREATE TABLE t (id INTEGER) -- trailing", "CREATE TABLE t (id INTEGER)"},
+	}
+	for _, test := range tests {
+		if normalized := normalizeSQL(test.stmt); normalized != test.expected {
+			t.Errorf("Expected %q to normalize to %q, but was %q", test.stmt, test.expected, normalized)
+		}
+	}
+}
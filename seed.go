@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The internal table that records which seeds have been applied.
+const seedTableName = internalPrefix + "seeds"
+
+// SeedRerunEnv - The environment variable that must be set to 1 to mark a development
+// environment, where a database opened WithSeedRerun may run seeds again.
+const SeedRerunEnv = "SQLDB_SEED_RERUN"
+
+// ErrSeedRerun - Seed was called on a database opened WithSeedRerun outside of a development environment.
+var ErrSeedRerun = errors.New("dberror: seeds can only be rerun when " + SeedRerunEnv + "=1")
+
+// SeedFunc - Load seed data into the database.
+type SeedFunc func(sdb *SQLDb) error
+
+var seeds = struct {
+	sync.RWMutex
+	names  []string
+	byName map[string]SeedFunc
+}{byName: make(map[string]SeedFunc)}
+
+// RegisterSeed - Register the function that loads the named seed data. Seeds are kept apart
+// from the schema patches, and are applied with Seed. Registering a name again replaces its
+// function, keeping its place in the registration order.
+func RegisterSeed(name string, fn SeedFunc) {
+	seeds.Lock()
+	defer seeds.Unlock()
+	if _, ok := seeds.byName[name]; !ok {
+		seeds.names = append(seeds.names, name)
+	}
+	seeds.byName[name] = fn
+}
+
+// UnregisterSeed - Remove the named seed. A record that it was applied is left in the database.
+func UnregisterSeed(name string) {
+	seeds.Lock()
+	defer seeds.Unlock()
+	if _, ok := seeds.byName[name]; !ok {
+		return
+	}
+	delete(seeds.byName, name)
+	for i, n := range seeds.names {
+		if n == name {
+			seeds.names = append(seeds.names[:i], seeds.names[i+1:]...)
+			break
+		}
+	}
+}
+
+func lookupSeed(name string) (SeedFunc, bool) {
+	seeds.RLock()
+	defer seeds.RUnlock()
+	fn, ok := seeds.byName[name]
+	return fn, ok
+}
+
+func seedNames() []string {
+	seeds.RLock()
+	defer seeds.RUnlock()
+	return append([]string(nil), seeds.names...)
+}
+
+// WithSeedRerun - Let Seed run seeds again that have already been applied, such as to reload
+// the data of a development database. As a guard against reseeding a production database,
+// where each seed must be applied only once, Seed returns ErrSeedRerun unless the SeedRerunEnv
+// environment variable is set to 1.
+func WithSeedRerun() Option {
+	return func(sdb *SQLDb) {
+		sdb.seedRerun = true
+	}
+}
+
+// Seed - Apply the named seeds, or every registered seed in registration order when no names
+// are given. A seed that has already been applied is skipped, unless the database was opened
+// WithSeedRerun. Each seed runs in its own transaction along with the record that it was applied.
+func (sdb *SQLDb) Seed(names ...string) error {
+	if sdb.readOnly {
+		return ErrReadOnly
+	}
+	if sdb.seedRerun && os.Getenv(SeedRerunEnv) != "1" {
+		return ErrSeedRerun
+	}
+	if len(names) == 0 {
+		names = seedNames()
+	}
+	for _, name := range names {
+		fn, ok := lookupSeed(name)
+		if !ok {
+			return fmt.Errorf("dberror: no such seed: %s", name)
+		}
+		if err := sdb.applySeed(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySeed runs the seed function on a dedicated connection while holding the database
+// write lock, like applyPatch, so concurrent handles apply a seed only once.
+func (sdb *SQLDb) applySeed(name string, fn SeedFunc) error {
+	return sdb.dedicated(func(tx *SQLDb) error {
+		return tx.applySeedTx(name, fn)
+	})
+}
+
+func (sdb *SQLDb) applySeedTx(name string, fn SeedFunc) error {
+	if err := sdb.BeginImmediateTrans(); err != nil {
+		return fmt.Errorf("dberror: could not begin seed %s: %v", name, err)
+	}
+	err := sdb.CreateTable(fmt.Sprintf("IF NOT EXISTS %s (name TEXT PRIMARY KEY, seeded TEXT NOT NULL)", seedTableName))
+	if err != nil {
+		sdb.RollbackTrans()
+		return fmt.Errorf("dberror: could not create the seed table: %v", err)
+	}
+	if !sdb.seedRerun {
+		seeded, err := sdb.QueryExists(fmt.Sprintf("SELECT name FROM %s WHERE name = ?", seedTableName), name)
+		if err != nil {
+			sdb.RollbackTrans()
+			return fmt.Errorf("dberror: could not check seed %s: %v", name, err)
+		}
+		if seeded {
+			return sdb.CommitTrans()
+		}
+	}
+	if err := sdb.callOrRollback(func() error { return fn(sdb) }, func() { sdb.RollbackTrans() }); err != nil {
+		sdb.RollbackTrans()
+		return fmt.Errorf("dberror: could not seed %s: %w", name, err)
+	}
+	err = sdb.Exec(fmt.Sprintf("INSERT OR REPLACE INTO %s (name, seeded) VALUES (?, CURRENT_TIMESTAMP)", seedTableName), name)
+	if err != nil {
+		sdb.RollbackTrans()
+		return fmt.Errorf("dberror: could not record seed %s: %v", name, err)
+	}
+	return sdb.CommitTrans()
+}
@@ -0,0 +1,81 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("colors (name TEXT)")
+		}},
+	})
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	runs := 0
+	RegisterSeed("colors", func(sdb *SQLDb) error {
+		runs++
+		return sdb.Exec("INSERT INTO colors (name) VALUES ('red'), ('green')")
+	})
+	defer UnregisterSeed("colors")
+	errSeed := errors.New("seed failed")
+	RegisterSeed("broken", func(sdb *SQLDb) error {
+		if err := sdb.Exec("INSERT INTO colors (name) VALUES ('blue')"); err != nil {
+			return err
+		}
+		return errSeed
+	})
+	defer UnregisterSeed("broken")
+
+	if err := sdb.Seed("colors"); err != nil {
+		t.Errorf("Seed error: %v", err)
+	}
+	if err := sdb.Seed("colors"); err != nil {
+		t.Errorf("Seed again error: %v", err)
+	}
+	if runs != 1 {
+		t.Errorf("Expected the seed to run once, but was %d", runs)
+	}
+	if err := sdb.Seed("broken"); !errors.Is(err, errSeed) {
+		t.Errorf("Expected the seed error, but was %v", err)
+	}
+	if err := sdb.Seed("missing"); err == nil {
+		t.Error("Expected an error seeding an unregistered seed")
+	}
+
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM colors", &count); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, but was %d: %v", count, err)
+	}
+	if seeded, err := sdb.QueryExists("SELECT name FROM " + seedTableName + " WHERE name = 'broken'"); err != nil || seeded {
+		t.Errorf("Expected the failed seed not to be recorded: %v, %v", seeded, err)
+	}
+
+	// Seeds are re-run only when the database is opened for it.
+	dev, err := OpenDb(testDbName, WithSeedRerun())
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &dev)
+	UnregisterSeed("broken")
+	if err := dev.Seed(); !errors.Is(err, ErrSeedRerun) {
+		t.Errorf("Expected ErrSeedRerun outside of a development environment, but was %v", err)
+	}
+	if runs != 1 {
+		t.Errorf("Expected the seed not to run again, but ran %d times", runs)
+	}
+	t.Setenv(SeedRerunEnv, "1")
+	if err := dev.Seed(); err != nil {
+		t.Errorf("Seed rerun error: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("Expected the seed to run twice, but was %d", runs)
+	}
+}
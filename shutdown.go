@@ -0,0 +1,236 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// How often a shutdown checks whether in-flight writes and transactions have finished.
+const drainPollInterval = 5 * time.Millisecond
+
+// ErrShuttingDown is returned for writes started outside of a transaction once the
+// database has begun shutting down.
+var ErrShuttingDown = errors.New("dberror: database is shutting down")
+
+// Every SQLDb opened by the package, so CloseAll can shut them down. They are tracked by
+// their activity, which the handles made from them, such as by RetryWith, share, so closing
+// any of the handles stops tracking the database.
+var openDbs = struct {
+	sync.Mutex
+	dbs map[*activity]*SQLDb
+}{dbs: make(map[*activity]*SQLDb)}
+
+func trackDb(sdb *SQLDb) {
+	openDbs.Lock()
+	defer openDbs.Unlock()
+	openDbs.dbs[sdb.activity] = sdb
+}
+
+func untrackDb(sdb *SQLDb) {
+	openDbs.Lock()
+	defer openDbs.Unlock()
+	delete(openDbs.dbs, sdb.activity)
+}
+
+// WithShutdownBackup - Take an online backup of the database to backupPath when it is shut
+// down by CloseAll, after in-flight work has drained.
+func WithShutdownBackup(backupPath string) Option {
+	return func(sdb *SQLDb) {
+		sdb.backupOnClose = backupPath
+	}
+}
+
+// CloseAll - Gracefully close every database opened by the package. Intended to be called from
+// the shutdown path of main, such as on SIGTERM. Each database is shut down as by Shutdown.
+// If ctx is done before the work has drained, the databases are closed anyway, which rolls back
+// any open transactions, and the context error is returned.
+func CloseAll(ctx context.Context) error {
+	openDbs.Lock()
+	dbs := make([]*SQLDb, 0, len(openDbs.dbs))
+	for _, sdb := range openDbs.dbs {
+		dbs = append(dbs, sdb)
+	}
+	openDbs.Unlock()
+
+	errs := make([]error, len(dbs))
+	var wg sync.WaitGroup
+	for i, sdb := range dbs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = sdb.shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Shutdown - Gracefully close the database. New writes outside of a transaction are refused,
+// in-flight writes and open transactions are allowed to finish, the query planner statistics
+// are optimized, the WAL is checkpointed, the optional shutdown backup is taken, and the
+// database is closed. If ctx is done before the work has drained, the database is closed anyway,
+// which rolls back any open transaction, and the context error is returned.
+func (sdb *SQLDb) Shutdown(ctx context.Context) error {
+	return sdb.shutdown(ctx)
+}
+
+// Close - Close the database, and stop tracking it for CloseAll.
+func (sdb *SQLDb) Close() error {
+	untrackDb(sdb)
+	if sdb.autoCheckpoint != nil {
+		sdb.autoCheckpoint.close()
+	}
+	return sdb.closeDB()
+}
+
+// shutdown drains in-flight work, checkpoints, optionally backs up, and closes the database.
+func (sdb *SQLDb) shutdown(ctx context.Context) error {
+	sdb.activity.setClosing()
+	drainErr := sdb.activity.drain(ctx)
+
+	var errs []error
+	if drainErr == nil {
+		// Fold the WAL back into the database file so it is complete on its own.
+		// This is a no-op for databases that are not in WAL mode.
+		if !sdb.readOnly {
+			// New writes are refused by now, so optimize on a dedicated connection directly.
+			if err := sdb.optimizeOnClose(); err != nil {
+				errs = append(errs, fmt.Errorf("dberror: final optimize: %v", err))
+			}
+			if _, err := sdb.WalCheckpoint(CheckpointTruncate); err != nil {
+				errs = append(errs, fmt.Errorf("dberror: final checkpoint: %v", err))
+			}
+		}
+		if sdb.backupOnClose != "" {
+			if err := sdb.BackupTo(sdb.backupOnClose); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := sdb.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(append([]error{drainErr}, errs...)...)
+}
+
+// optimizeOnClose optimizes the query planner statistics on a dedicated connection,
+// which is the writer in single writer mode.
+func (sdb *SQLDb) optimizeOnClose() error {
+	return sdb.dedicated(func(tx *SQLDb) error {
+		_, err := tx.conn.ExecContext(context.Background(), "PRAGMA optimize")
+		return err
+	})
+}
+
+// activity tracks in-flight writes and open transactions so they can be drained on shutdown.
+// It is shared by a handle and the handles bound to its connections, which each keep their
+// own txState.
+type activity struct {
+	mu      sync.Mutex
+	writes  int
+	open    int
+	closing bool
+}
+
+// txState is the transaction state of one handle, guarded by the mutex of its activity.
+type txState struct {
+	depth int
+}
+
+// enterWrite registers an in-flight write. Once closing, writes are only allowed inside an
+// open transaction, so that it can be finished.
+func (a *activity) enterWrite(ts *txState) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closing && ts.depth == 0 {
+		return ErrShuttingDown
+	}
+	a.writes++
+	return nil
+}
+
+func (a *activity) leaveWrite() {
+	a.mu.Lock()
+	a.writes--
+	a.mu.Unlock()
+}
+
+func (a *activity) beginTrans(ts *txState) {
+	a.mu.Lock()
+	a.setDepth(ts, 1)
+	a.mu.Unlock()
+}
+
+func (a *activity) endTrans(ts *txState) {
+	a.mu.Lock()
+	a.setDepth(ts, 0)
+	a.mu.Unlock()
+}
+
+// beginSavePoint records a save point, which begins a transaction if none is open.
+func (a *activity) beginSavePoint(ts *txState) {
+	a.mu.Lock()
+	a.setDepth(ts, ts.depth+1)
+	a.mu.Unlock()
+}
+
+func (a *activity) endSavePoint(ts *txState) {
+	a.mu.Lock()
+	if ts.depth > 0 {
+		a.setDepth(ts, ts.depth-1)
+	}
+	a.mu.Unlock()
+}
+
+// setDepth sets the depth of the handle's transaction, and counts whether it is open.
+func (a *activity) setDepth(ts *txState, depth int) {
+	switch {
+	case ts.depth == 0 && depth > 0:
+		a.open++
+	case ts.depth > 0 && depth == 0:
+		a.open--
+	}
+	ts.depth = depth
+}
+
+// inTransaction reports whether the handle has a transaction or save point open.
+func (a *activity) inTransaction(ts *txState) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return ts.depth > 0
+}
+
+func (a *activity) setClosing() {
+	a.mu.Lock()
+	a.closing = true
+	a.mu.Unlock()
+}
+
+func (a *activity) isClosing() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closing
+}
+
+func (a *activity) idle() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.writes == 0 && a.open == 0
+}
+
+// drain waits until there are no in-flight writes or open transactions, or ctx is done.
+func (a *activity) drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for !a.idle() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
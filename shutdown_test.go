@@ -0,0 +1,134 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+const testShutdownBackupName = "TestShutdownBackupDb"
+
+func TestCloseAll(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+	defer os.Remove(testShutdownBackupName)
+
+	sdb, err := OpenAndPatchDb(testWalDbName, nil, WithShutdownBackup(testShutdownBackupName))
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	// An open transaction is allowed to finish before the database is closed.
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			t.Errorf("Insert during shutdown error: %v", err)
+		}
+		if err := sdb.CommitTrans(); err != nil {
+			t.Errorf("CommitTrans during shutdown error: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := CloseAll(ctx); err != nil {
+		t.Fatalf("CloseAll error: %v", err)
+	}
+	if err := sdb.Ping(); err == nil {
+		t.Error("Expected database to be closed")
+	}
+	if info, err := os.Stat(testDbName + "-wal"); err == nil && info.Size() > 0 {
+		t.Error("Expected WAL to be checkpointed")
+	}
+
+	backup, err := OpenDb(testShutdownBackupName)
+	if err != nil {
+		t.Fatalf("OpenDb backup error: %v", err)
+	}
+	defer closeDb(t, &backup)
+	exists, err := backup.QueryExists("SELECT id FROM testtable")
+	if err != nil || !exists {
+		t.Errorf("Expected committed row in shutdown backup: %v, %v", exists, err)
+	}
+}
+
+func TestCloseAll_Timeout(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.BeginTrans(); err != nil {
+		t.Fatalf("BeginTrans error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := CloseAll(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, but was %v", err)
+	}
+
+	// The abandoned transaction was rolled back by closing the database.
+	sdb = openTestDb(t)
+	defer closeDb(t, &sdb)
+	exists, err := sdb.QueryExists("SELECT id FROM testtable")
+	if err != nil || exists {
+		t.Errorf("Expected open transaction to be rolled back: %v, %v", exists, err)
+	}
+}
+
+func TestShutdown_RefusesNewWrites(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	sdb.activity.setClosing()
+	if err := sdb.Exec("INSERT INTO gkey (next) VALUES (10)"); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, but was %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testWalDbName, nil)
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	other := openTestDb(t)
+	defer closeDb(t, &other)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sdb.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	if err := sdb.Ping(); err == nil {
+		t.Error("Expected database to be closed")
+	}
+	if info, err := os.Stat(testDbName + "-wal"); err == nil && info.Size() > 0 {
+		t.Error("Expected WAL to be checkpointed")
+	}
+	// Only the database that was shut down is closed.
+	if err := other.Ping(); err != nil {
+		t.Errorf("Expected other database to stay open: %v", err)
+	}
+}
@@ -1,47 +1,84 @@
 package sqldb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	// Extend the sql.DB structure to the SQLDb structure.
-	_ "github.com/mattn/go-sqlite3"
+	sqlite3 "github.com/mattn/go-sqlite3"
 )
 
-const patchSavePointName = "patchupdate"
+// ErrNoRows is returned by SingleQuery when the query does not match any rows.
+var ErrNoRows = errors.New("dberror: no rows in result set")
 
 // SQLDb - SQL Database wrapper with extended patching functions.
 type SQLDb struct {
 	*sql.DB
-}
+	filename       string
+	opts           []Option
+	wal            *walMonitor
+	activity       *activity
+	txn            *txState
+	hooks          *queryHooks
+	scans          *scanWarner
+	metrics        Metrics
+	retry          RetryPolicy
+	readOnly       bool
+	seedRerun      bool
+	savePoints     *savePointStack
+	conns          *connections
+	changes        *changeNotifier
+	encryptionKey  string
+	conn           *sql.Conn
+	pool           []func(db *sql.DB)
+	singleWriter   bool
+	writer         *writer
+	autoCheckpoint *autoCheckpointer
+	backupOnClose  string
+	recoverPanics  bool
+	mapper         *structMapper
+}
+
+// Option - Configure optional behavior of a SQLDb when it is opened.
+type Option func(sdb *SQLDb)
 
 // PatchFuncType contains unique patch ID and a patch function to run.
 type PatchFuncType struct {
 	// PatchID is not necessarily sequential. It just needs to be unique, but convention is sequential.
 	PatchID int
-	// PatchFunc will perform patch operations on the database.
+	// PatchFunc will perform patch operations on the database. It is passed a handle bound
+	// to the connection of the patch transaction, which every statement must be run through.
 	PatchFunc func(sdb *SQLDb) error
+	// DependsOn lists the IDs of the patches that must be applied before this one. Patches are
+	// applied in the order given, except that each is moved after the patches it depends on.
+	DependsOn []int
 }
 
 // The array of patch functions that will automatically upgrade the database.
 // Internal patch IDs are reserved to be zero or negative. User patch IDs are positive ints.
 var internalPatchDbFuncs = []PatchFuncType{
-	{0, func(sdb *SQLDb) error {
+	{PatchID: 0, PatchFunc: func(sdb *SQLDb) error {
 		return sdb.CreateTable("IF NOT EXISTS version (patchid INTEGER PRIMARY KEY)")
 	}},
-	{-1, func(sdb *SQLDb) error {
+	{PatchID: -1, PatchFunc: func(sdb *SQLDb) error {
 		if err := sdb.CreateTable("IF NOT EXISTS gkey (next INTEGER PRIMARY KEY)"); err != nil {
-			return nil
+			return err
 		}
-		// Insert initial value of 1 into the gkey table
-		return sdb.Exec("INSERT INTO gkey (next) VALUES (1)")
+		// Insert initial value of 1 into the gkey table, unless another handle already has.
+		return sdb.Exec("INSERT INTO gkey (next) SELECT 1 WHERE NOT EXISTS (SELECT next FROM gkey)")
+	}},
+	{PatchID: -2, PatchFunc: func(sdb *SQLDb) error {
+		return sdb.CreateTable(fmt.Sprintf("IF NOT EXISTS %s (key TEXT PRIMARY KEY, value TEXT NOT NULL)", kvTableName))
 	}},
 }
 
 // OpenAndPatchDb - Open and Patch a database if necessary.
-func OpenAndPatchDb(dbFilename string, patchFuncs []PatchFuncType) (*SQLDb, error) {
-	sdb, err := OpenDb(dbFilename)
+func OpenAndPatchDb(dbFilename string, patchFuncs []PatchFuncType, opts ...Option) (*SQLDb, error) {
+	sdb, err := OpenDb(dbFilename, opts...)
 	if err != nil {
 		return sdb, err
 	}
@@ -52,21 +89,67 @@ func OpenAndPatchDb(dbFilename string, patchFuncs []PatchFuncType) (*SQLDb, erro
 }
 
 // OpenDb - Open a database.
-func OpenDb(dbFilename string) (*SQLDb, error) {
-	var err error
-	sdb := &SQLDb{}
-	sdb.DB, err = sql.Open("sqlite3", dbFilename)
-	if err != nil {
+func OpenDb(dbFilename string, opts ...Option) (*SQLDb, error) {
+	sdb := &SQLDb{
+		filename:   dbFilename,
+		opts:       opts,
+		wal:        newWalMonitor(dbFilename),
+		activity:   &activity{},
+		txn:        &txState{},
+		hooks:      &queryHooks{},
+		metrics:    nopMetrics{},
+		savePoints: &savePointStack{},
+		conns:      newConnections(),
+		changes:    newChangeNotifier(),
+		mapper:     newStructMapper(nil),
+	}
+	for _, opt := range opts {
+		opt(sdb)
+	}
+	if sdb.encryptionKey != "" && !encryptionSupported {
+		return sdb, ErrEncryptionUnsupported
+	}
+	if err := sdb.open(); err != nil {
 		return sdb, err
 	}
-	if nil != sdb.DB.Ping() {
-		return sdb, fmt.Errorf("could not communicate with database: %s", dbFilename)
+	if sdb.autoCheckpoint != nil && !sdb.readOnly {
+		sdb.autoCheckpoint.start(sdb)
+	} else {
+		sdb.autoCheckpoint = nil
 	}
+	trackDb(sdb)
 	return sdb, nil
 }
 
+// open connects the wrapped sql.DB to the database file.
+func (sdb *SQLDb) open() error {
+	sdb.DB = sql.OpenDB(&connector{
+		dsn:     sdb.filename,
+		driver:  &sqlite3.SQLiteDriver{ConnectHook: sdb.connectHook},
+		conns:   sdb.conns,
+		changes: sdb.changes,
+	})
+	for _, apply := range sdb.pool {
+		apply(sdb.DB)
+	}
+	if nil != sdb.DB.Ping() {
+		return fmt.Errorf("could not communicate with database: %s", sdb.filename)
+	}
+	if sdb.singleWriter && !sdb.readOnly {
+		w, err := newWriter(sdb.DB)
+		if err != nil {
+			return err
+		}
+		sdb.writer = w
+	}
+	return sdb.wal.init(sdb)
+}
+
 // PatchDb - Patch a database if necessary.
 func (sdb *SQLDb) PatchDb(patchFuncs []PatchFuncType) error {
+	if sdb.readOnly {
+		return ErrReadOnly
+	}
 	// Always run internal patch functions first
 	if err := sdb.patch(internalPatchDbFuncs); err != nil {
 		return err
@@ -80,32 +163,83 @@ func (sdb *SQLDb) PatchDb(patchFuncs []PatchFuncType) error {
 }
 
 func (sdb *SQLDb) patch(patchFuncs []PatchFuncType) error {
+	patchFuncs, err := orderPatches(patchFuncs)
+	if err != nil {
+		return err
+	}
 	// Currently this patching function does not check to see when it is
 	// finished whether it is running against a _newer_ database. An additional
 	// check would need to be done to see if the final committed patchid matches the
 	// expected patchid.
 	for _, patch := range patchFuncs {
-		if !sdb.patched(patch.PatchID) {
-			if err := sdb.beginPatch(); err != nil {
-				return fmt.Errorf("could not begin patch database for version %d: %v", patch.PatchID, err)
-			}
-			if err := patch.PatchFunc(sdb); err != nil {
-				sdb.rollbackPatch()
-				return fmt.Errorf("could not patch database for version %d: %v", patch.PatchID, err)
-			}
-			if err := sdb.commitPatch(patch.PatchID); err != nil {
-				sdb.rollbackPatch()
-				return fmt.Errorf("could not commit patch database for version %d: %v", patch.PatchID, err)
+		patched, err := sdb.patched(patch.PatchID)
+		if err != nil {
+			return fmt.Errorf("could not check database for version %d: %v", patch.PatchID, err)
+		}
+		if !patched {
+			if err := sdb.applyPatch(patch); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
+// applyPatch runs the patch function while holding the database write lock, so that
+// several handles patching the same database at once each apply it exactly once.
+// The patch runs on a dedicated connection, so every statement the patch function
+// runs through the handle it is passed is part of the patch transaction.
+func (sdb *SQLDb) applyPatch(patch PatchFuncType) error {
+	return sdb.dedicated(func(tx *SQLDb) error {
+		return tx.applyPatchTx(patch)
+	})
+}
+
+func (sdb *SQLDb) applyPatchTx(patch PatchFuncType) error {
+	if err := sdb.beginPatch(); err != nil {
+		return fmt.Errorf("could not begin patch database for version %d: %v", patch.PatchID, err)
+	}
+	// Another handle may have applied the patch while waiting for the write lock.
+	patched, err := sdb.patched(patch.PatchID)
+	if err != nil {
+		sdb.rollbackPatch()
+		return fmt.Errorf("could not check database for version %d: %v", patch.PatchID, err)
+	}
+	if patched {
+		return sdb.CommitTrans()
+	}
+	start := time.Now()
+	err = sdb.callOrRollback(func() error { return patch.PatchFunc(sdb) }, sdb.rollbackPatch)
+	sdb.metrics.Patch(patch.PatchID, time.Since(start), err)
+	if err != nil {
+		sdb.rollbackPatch()
+		return fmt.Errorf("could not patch database for version %d: %w", patch.PatchID, err)
+	}
+	if err := sdb.commitPatch(patch.PatchID); err != nil {
+		sdb.rollbackPatch()
+		return fmt.Errorf("could not commit patch database for version %d: %v", patch.PatchID, err)
+	}
+	return nil
+}
+
 // GetGkey - Get a gkey to be used as unique record ID
 func (sdb *SQLDb) GetGkey() (int, error) {
+	var gkey int
+	err := sdb.writeTx(func(tx *SQLDb) (err error) {
+		gkey, err = tx.nextGkey()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	sdb.metrics.GkeyAllocated()
+	return gkey, nil
+}
+
+func (sdb *SQLDb) nextGkey() (int, error) {
 	// Read next value from gkey table. Increment gkey table next value.
-	if err := sdb.BeginTrans(); err != nil {
+	// Take the write lock up front so concurrent handles wait rather than fail to upgrade their read lock.
+	if err := sdb.BeginImmediateTrans(); err != nil {
 		return 0, err
 	}
 
@@ -120,21 +254,52 @@ func (sdb *SQLDb) GetGkey() (int, error) {
 		return 0, err
 	}
 
-	return gkey, sdb.CommitTrans()
+	if err := sdb.CommitTrans(); err != nil {
+		return 0, err
+	}
+	return gkey, nil
 }
 
 // BeginTrans - Begin transaction
 func (sdb *SQLDb) BeginTrans() error {
-	return sdb.Exec("BEGIN")
+	if err := sdb.checkTxHandle(); err != nil {
+		return err
+	}
+	if err := sdb.Exec("BEGIN"); err != nil {
+		return err
+	}
+	sdb.activity.beginTrans(sdb.txn)
+	sdb.metrics.Transaction()
+	return nil
+}
+
+// BeginImmediateTrans - Begin transaction, and immediately acquire the database write lock.
+func (sdb *SQLDb) BeginImmediateTrans() error {
+	if err := sdb.checkTxHandle(); err != nil {
+		return err
+	}
+	if err := sdb.Exec("BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+	sdb.activity.beginTrans(sdb.txn)
+	sdb.metrics.Transaction()
+	return nil
 }
 
 // CommitTrans - Commit transaction
 func (sdb *SQLDb) CommitTrans() error {
-	return sdb.Exec("COMMIT")
+	if err := sdb.Exec("COMMIT"); err != nil {
+		return err
+	}
+	sdb.activity.endTrans(sdb.txn)
+	return nil
 }
 
 // RollbackTrans - Rollback transaction
 func (sdb *SQLDb) RollbackTrans() error {
+	// Whether or not it succeeds, there is no transaction left open.
+	defer sdb.activity.endTrans(sdb.txn)
+	sdb.metrics.Rollback()
 	return sdb.Exec("ROLLBACK")
 }
 
@@ -177,29 +342,56 @@ func (sdb *SQLDb) CommitSavePointOnNoError(name string, err error) error {
 }
 
 // ExecWithSavePoint - Execute the database function wrapped inside of a named Save Point.
+// If the function panics, the save point is rolled back before the panic continues.
 func (sdb *SQLDb) ExecWithSavePoint(spName string, fn func() error) error {
 	if err := sdb.CreateSavePoint(spName); err != nil {
 		return err
 	}
+	err := sdb.callOrRollback(fn, func() { sdb.RollbackSavePoint(spName) })
 	// Commit if the function has no errors
-	return sdb.CommitSavePointOnNoError(spName, fn())
+	return sdb.CommitSavePointOnNoError(spName, err)
+}
+
+// WithTransaction - Execute the database function inside a transaction on a connection of its
+// own, which is committed if it returns nil and rolled back otherwise. Every statement must be
+// run through the handle the function is passed. If the function panics, the transaction is
+// rolled back before the panic continues. It runs as InTx does, so in single writer mode it is
+// one job of the writer, and through a handle that already has a transaction open, it runs
+// inside a save point of it instead.
+func (sdb *SQLDb) WithTransaction(fn func(tx *SQLDb) error) error {
+	return sdb.InTx(func(tx *SQLTx) error { return fn(tx.SQLDb) })
 }
 
-func (sdb *SQLDb) patched(patchid int) bool {
+// transaction runs fn inside a transaction begun on the handle, which is committed if fn returns
+// nil and rolled back otherwise, or if fn panics. The handle must be bound to a connection.
+func (sdb *SQLDb) transaction(fn func() error) error {
+	if err := sdb.BeginTrans(); err != nil {
+		return err
+	}
+	err := sdb.callOrRollback(fn, func() { sdb.RollbackTrans() })
+	return sdb.CommitOnNoError(err)
+}
+
+func (sdb *SQLDb) patched(patchid int) (bool, error) {
+	// The version table does not exist until the first internal patch creates it.
+	exists, err := sdb.TableExists("version")
+	if err != nil || !exists {
+		return false, err
+	}
 	// Check for the patchid in the version table
-	return nil == sdb.SingleQuery(fmt.Sprintf("SELECT patchid FROM version WHERE patchid = %d", patchid))
+	return sdb.QueryExists("SELECT patchid FROM version WHERE patchid = ?", patchid)
 }
 
 func (sdb *SQLDb) beginPatch() error {
-	return sdb.CreateSavePoint(patchSavePointName)
+	return sdb.BeginImmediateTrans()
 }
 
 func (sdb *SQLDb) commitPatch(patchid int) error {
 	// Add the patchid to the versions table. If it fails, return false.
-	if err := sdb.Exec(fmt.Sprintf("INSERT OR FAIL INTO version (patchid) VALUES (%d)", patchid)); err != nil {
+	if err := sdb.Exec("INSERT OR IGNORE INTO version (patchid) VALUES (?)", patchid); err != nil {
 		return err
 	}
-	return sdb.CommitSavePoint(patchSavePointName)
+	return sdb.CommitTrans()
 }
 
 func (sdb *SQLDb) rollbackPatch() {
@@ -208,19 +400,37 @@ func (sdb *SQLDb) rollbackPatch() {
 
 // CreateSavePoint - Create a save point for rollback or commit.
 func (sdb *SQLDb) CreateSavePoint(name string) error {
-	return sdb.Exec(fmt.Sprintf("SAVEPOINT %s", name))
+	if err := sdb.checkTxHandle(); err != nil {
+		return err
+	}
+	if err := sdb.Exec(fmt.Sprintf("SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+	sdb.activity.beginSavePoint(sdb.txn)
+	return nil
 }
 
 // CommitSavePoint - Commit up to the named save point, which rolls it up into parent transaction.
 func (sdb *SQLDb) CommitSavePoint(name string) error {
-	return sdb.Exec(fmt.Sprintf("RELEASE SAVEPOINT %s", name))
+	if err := sdb.Exec(fmt.Sprintf("RELEASE SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+	sdb.activity.endSavePoint(sdb.txn)
+	return nil
 }
 
 // RollbackSavePoint - Rollback a save point
 func (sdb *SQLDb) RollbackSavePoint(name string) error {
+	if sdb.txnAborted() {
+		// SQLite rolled back the whole transaction, and the save point with it.
+		sdb.activity.endTrans(sdb.txn)
+		sdb.metrics.Rollback()
+		return nil
+	}
 	if err := sdb.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", name)); err != nil {
 		return err
 	}
+	sdb.metrics.Rollback()
 	return sdb.CommitSavePoint(name)
 }
 
@@ -239,54 +449,196 @@ func (sdb *SQLDb) CreateIndex(indexDef string) error {
 	return sdb.Exec(fmt.Sprintf("CREATE INDEX %s", indexDef))
 }
 
-// ExecResults - Execute the statement with the bound arguments.
+// CreateTrigger - Create the trigger definition.
+func (sdb *SQLDb) CreateTrigger(triggerDef string) error {
+	return sdb.Exec(fmt.Sprintf("CREATE TRIGGER %s", triggerDef))
+}
+
+// CreateTriggerIfNotExists - Create the trigger definition, unless a trigger with its name exists.
+func (sdb *SQLDb) CreateTriggerIfNotExists(triggerDef string) error {
+	return sdb.CreateTrigger("IF NOT EXISTS " + triggerDef)
+}
+
+// DropTrigger - Drop the trigger.
+func (sdb *SQLDb) DropTrigger(name string) error {
+	return sdb.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s", name))
+}
+
+// CreateView - Create the view definition.
+func (sdb *SQLDb) CreateView(viewDef string) error {
+	return sdb.Exec(fmt.Sprintf("CREATE VIEW %s", viewDef))
+}
+
+// CreateViewIfNotExists - Create the view definition, unless a view with its name exists.
+func (sdb *SQLDb) CreateViewIfNotExists(viewDef string) error {
+	return sdb.CreateView("IF NOT EXISTS " + viewDef)
+}
+
+// DropView - Drop the view.
+func (sdb *SQLDb) DropView(name string) error {
+	return sdb.Exec(fmt.Sprintf("DROP VIEW IF EXISTS %s", name))
+}
+
+// ExecResults - Execute the statement with the bound arguments. Only the first statement of
+// the text is executed. A single statement without arguments is run directly, without
+// preparing it first.
 func (sdb *SQLDb) ExecResults(stmt string, args ...interface{}) (sql.Result, error) {
-	statement, err := sdb.Prepare(stmt)
-	defer closeStmt(statement)
-	if err != nil {
-		return nil, fmt.Errorf("dberror: preparing %s: %v", stmt, err)
-	}
 	var res sql.Result
-	res, err = statement.Exec(args...)
+	err := sdb.write(stmt, args, func(runner sqlRunner, args []interface{}) (stage string, err error) {
+		if len(args) == 0 && !hasTail(stmt) {
+			// Without arguments to bind, skip the round trip of preparing and closing the statement.
+			// Executing directly would run every statement of the text, so it is only done for one.
+			res, err = runner.ExecContext(context.Background(), stmt)
+			return "executing", err
+		}
+		statement, err := runner.PrepareContext(context.Background(), stmt)
+		defer closeStmt(statement)
+		if err != nil {
+			return "preparing", err
+		}
+		res, err = statement.Exec(args...)
+		return "executing", err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("dberror: executing %s: %v", stmt, err)
+		return nil, err
 	}
 	return res, nil
 }
 
+// write runs a statement that writes to the database with the run function, which returns the
+// stage it failed at. In single writer mode, it runs on the writer. Every write is checked
+// against read-only mode, counted as in flight for draining, and retried, and fires the hooks.
+func (sdb *SQLDb) write(stmt string, args []interface{}, run func(runner sqlRunner, args []interface{}) (stage string, err error)) (err error) {
+	defer sdb.recoverPanic(&err)
+	if err := sdb.checkWritable(stmt); err != nil {
+		return err
+	}
+	if err := sdb.activity.enterWrite(sdb.txn); err != nil {
+		return err
+	}
+	defer sdb.activity.leaveWrite()
+	if args, err = convertArgs(args); err != nil {
+		return err
+	}
+	start := time.Now()
+	defer func() { sdb.hooks.fire(stmt, args, time.Since(start), err) }()
+	stage := "preparing"
+	exec := func(runner sqlRunner) error {
+		return sdb.retryPolicy(stmt).Do(func() (err error) {
+			stage, err = run(runner, args)
+			return err
+		})
+	}
+	if sdb.writer != nil && sdb.conn == nil {
+		err = sdb.writer.do(func(conn *sql.Conn) error { return exec(conn) })
+	} else {
+		err = exec(sdb.runner())
+	}
+	if err != nil {
+		return fmt.Errorf("dberror: %s %s: %w", stage, stmt, err)
+	}
+	sdb.wal.observe()
+	return nil
+}
+
 // Exec - Execute the statement with the bound arguments.
 func (sdb *SQLDb) Exec(stmt string, args ...interface{}) error {
 	_, err := sdb.ExecResults(stmt, args...)
 	return err
 }
 
+// ExecScript - Execute every statement of the script, such as a schema or a fixture, inside
+// a save point, so either all of them are applied or none are.
+func (sdb *SQLDb) ExecScript(script string) error {
+	return sdb.inSavePoint(func(tx *SQLDb) error {
+		return tx.write(script, nil, func(runner sqlRunner, _ []interface{}) (string, error) {
+			// Without arguments, the driver executes every statement of the text.
+			_, err := runner.ExecContext(context.Background(), script)
+			return "executing", err
+		})
+	})
+}
+
 // SingleQuery - Query the database, and retrieve the results. Expected single value return.
+// Returns an error wrapping ErrNoRows if the query does not match any rows.
 func (sdb *SQLDb) SingleQuery(stmt string, args ...interface{}) error {
-	rows, err := sdb.Query(stmt)
-	defer closeRows(rows)
+	return sdb.SingleQueryArgs(stmt, nil, args...)
+}
+
+// SingleQueryArgs - Query the database with the bound arguments, and scan the first returned
+// row into dest. Returns an error wrapping ErrNoRows if the query does not match any rows.
+func (sdb *SQLDb) SingleQueryArgs(stmt string, args []interface{}, dest ...interface{}) (err error) {
+	defer sdb.recoverPanic(&err)
+	if args, err = convertArgs(args); err != nil {
+		return err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
 	if err != nil {
 		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
 	}
 	if rows.Next() {
-		if args != nil {
-			return rows.Scan(args...)
+		if dest != nil {
+			return ScanRow(rows, dest...)
 		}
 		return nil
 	}
-	return fmt.Errorf("dberror: could not retrieve query value for %s", stmt)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return fmt.Errorf("dberror: could not retrieve query value for %s: %w", stmt, ErrNoRows)
+}
+
+// QueryExists - Query the database with the bound arguments, and report whether any rows match.
+func (sdb *SQLDb) QueryExists(stmt string, args ...interface{}) (_ bool, err error) {
+	defer sdb.recoverPanic(&err)
+	if args, err = convertArgs(args); err != nil {
+		return false, err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
+	if err != nil {
+		return false, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	if rows.Next() {
+		return true, nil
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("dberror: querying %s: %v", stmt, err)
+	}
+	return false, nil
 }
 
 // MultiQuery - Execute a function on the returned query rows.
 func (sdb *SQLDb) MultiQuery(stmt string, action func(rows *sql.Rows) error) error {
-	rows, err := sdb.Query(stmt)
-	defer closeRows(rows)
+	return sdb.MultiQueryArgs(stmt, nil, func(rows *sql.Rows) (bool, error) {
+		return false, action(rows)
+	})
+}
+
+// MultiQueryArgs - Query the database with the bound arguments, and execute a function on
+// each returned row until it returns stop or an error.
+func (sdb *SQLDb) MultiQueryArgs(stmt string, args []interface{}, action func(rows *sql.Rows) (stop bool, err error)) (err error) {
+	defer sdb.recoverPanic(&err)
+	if args, err = convertArgs(args); err != nil {
+		return err
+	}
+	rows, err := sdb.query(stmt, args...)
+	defer sdb.releaseRows(rows)
 	if err != nil {
 		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
 	}
 	for rows.Next() {
-		if err := action(rows); err != nil {
+		stop, err := action(rows)
+		if err != nil {
 			return err
 		}
+		if stop {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("dberror: querying %s: %v", stmt, err)
 	}
 	return nil
 }
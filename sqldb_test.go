@@ -1,8 +1,11 @@
 package sqldb
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 
 	gocommon "github.com/semog/go-common"
@@ -12,17 +15,19 @@ import (
 const testFolderName = ".testspace"
 const testDbName = "TestDb"
 
-func setupTests(_ *testing.T) {
+func setupTests(_ testing.TB) {
 	removeTempFiles()
 }
 
-func cleanupTests(_ *testing.T) {
+func cleanupTests(_ testing.TB) {
 	removeTempFiles()
 }
 
 func removeTempFiles() {
-	if gocommon.FileExists(testDbName) {
-		os.Remove(testDbName)
+	for _, name := range []string{testDbName, testDbName + "-wal", testDbName + "-shm"} {
+		if gocommon.FileExists(name) {
+			os.Remove(name)
+		}
 	}
 }
 
@@ -52,7 +57,7 @@ func openPatchedTestDb(t *testing.T) *SQLDb {
 	return sdb
 }
 
-func closeDb(t *testing.T, sdb **SQLDb) {
+func closeDb(t testing.TB, sdb **SQLDb) {
 	if *sdb != nil {
 		err := (*sdb).Close()
 		if err != nil {
@@ -240,6 +245,78 @@ func TestCreateIndexNonExistingTable(t *testing.T) {
 		t.Error("CreateIndex did not return an error")
 	}
 }
+
+func TestCreateDropTrigger(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 TEXT)"); err != nil {
+		t.Errorf("CreateTable error: %v", err)
+	}
+
+	trigger := "test_trg AFTER INSERT ON testtable BEGIN UPDATE testtable SET field1 = 'set' WHERE id = NEW.id; END"
+	if err := sdb.CreateTrigger(trigger); err != nil {
+		t.Errorf("CreateTrigger error: %v", err)
+	}
+	if err := sdb.CreateTrigger(trigger); err == nil {
+		t.Error("CreateTrigger of an existing trigger did not return an error")
+	}
+	if err := sdb.CreateTriggerIfNotExists(trigger); err != nil {
+		t.Errorf("CreateTriggerIfNotExists error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+	var field1 string
+	if err := sdb.SingleQuery("SELECT field1 FROM testtable", &field1); err != nil || field1 != "set" {
+		t.Errorf("Expected the trigger to set field1, but was %s: %v", field1, err)
+	}
+
+	if err := sdb.DropTrigger("test_trg"); err != nil {
+		t.Errorf("DropTrigger error: %v", err)
+	}
+	if err := sdb.DropTrigger("test_trg"); err != nil {
+		t.Errorf("DropTrigger of a dropped trigger error: %v", err)
+	}
+}
+
+func TestCreateDropView(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 TEXT)"); err != nil {
+		t.Errorf("CreateTable error: %v", err)
+	}
+
+	view := "test_view AS SELECT id FROM testtable WHERE field1 IS NOT NULL"
+	if err := sdb.CreateView(view); err != nil {
+		t.Errorf("CreateView error: %v", err)
+	}
+	if err := sdb.CreateView(view); err == nil {
+		t.Error("CreateView of an existing view did not return an error")
+	}
+	if err := sdb.CreateViewIfNotExists(view); err != nil {
+		t.Errorf("CreateViewIfNotExists error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id, field1) VALUES (1, 'a'), (2, NULL)"); err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM test_view", &count); err != nil || count != 1 {
+		t.Errorf("Expected 1 row in the view, but was %v: %v", count, err)
+	}
+
+	if err := sdb.DropView("test_view"); err != nil {
+		t.Errorf("DropView error: %v", err)
+	}
+	if err := sdb.DropView("test_view"); err != nil {
+		t.Errorf("DropView of a dropped view error: %v", err)
+	}
+}
 func testGkey(t *testing.T, err error, expected, actual int) {
 	if err != nil {
 		t.Errorf("GetKey error: %v", err)
@@ -272,3 +349,327 @@ func TestGetGkey(t *testing.T) {
 	gkey, err = sdb.GetGkey()
 	testGkey(t, err, 3, gkey)
 }
+
+func TestSingleQueryNoRows(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Errorf("CreateTable error: %v", err)
+	}
+
+	var id int
+	err := sdb.SingleQuery("SELECT id FROM testtable", &id)
+	if !errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected ErrNoRows, but was %v", err)
+	}
+
+	err = sdb.SingleQuery("SELECT id FROM notatable", &id)
+	if err == nil || errors.Is(err, ErrNoRows) {
+		t.Errorf("Expected query error, but was %v", err)
+	}
+}
+
+func TestQueryExists(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Errorf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (?)", 1); err != nil {
+		t.Errorf("Insert error: %v", err)
+	}
+
+	exists, err := sdb.QueryExists("SELECT id FROM testtable WHERE id = ?", 1)
+	if err != nil || !exists {
+		t.Errorf("Expected row to exist: %v, %v", exists, err)
+	}
+	exists, err = sdb.QueryExists("SELECT id FROM testtable WHERE id = ?", 2)
+	if err != nil || exists {
+		t.Errorf("Expected row to not exist: %v, %v", exists, err)
+	}
+	if _, err = sdb.QueryExists("SELECT id FROM notatable"); err == nil {
+		t.Error("QueryExists did not return an error")
+	}
+}
+
+func TestWithTransaction(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	if err := sdb.WithTransaction(func(tx *SQLDb) error {
+		if err := tx.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+			return err
+		}
+		// The transaction runs on one connection, so other connections do not see the row yet.
+		var count int
+		if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 0 {
+			t.Errorf("Expected no committed rows inside the transaction, but was %v: %v", count, err)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("WithTransaction error: %v", err)
+	}
+	fnErr := errors.New("transaction failure")
+	if err := sdb.WithTransaction(func(tx *SQLDb) error {
+		if err := tx.Exec("INSERT INTO testtable (id) VALUES (2)"); err != nil {
+			return err
+		}
+		return fnErr
+	}); err != fnErr {
+		t.Errorf("Expected transaction error, but was %v", err)
+	}
+
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 1 {
+		t.Errorf("Expected 1 committed row, but was %v: %v", count, err)
+	}
+}
+
+func TestMultiQueryArgs(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for id := 1; id <= 5; id++ {
+		if err := sdb.Exec("INSERT INTO testtable (id) VALUES (?)", id); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	var ids []int
+	err := sdb.MultiQueryArgs("SELECT id FROM testtable WHERE id > ? ORDER BY id", []interface{}{1},
+		func(rows *sql.Rows) (bool, error) {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return false, err
+			}
+			ids = append(ids, id)
+			return id == 3, nil
+		})
+	if err != nil {
+		t.Errorf("MultiQueryArgs error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("Expected ids 2 and 3, but was %v", ids)
+	}
+
+	if err := sdb.MultiQueryArgs("SELECT id FROM notatable", nil, func(rows *sql.Rows) (bool, error) {
+		return false, nil
+	}); err == nil {
+		t.Error("MultiQueryArgs did not return an error")
+	}
+}
+
+func TestExec_MultipleStatements(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	// Only the first statement runs, with or without arguments.
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1); INSERT INTO testtable (id) VALUES (2)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (?); INSERT INTO testtable (id) VALUES (4)", 3); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, but was %v: %v", count, err)
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	err := sdb.ExecScript(`CREATE TABLE testtable (id INTEGER PRIMARY KEY);
+		-- Every statement runs.
+		INSERT INTO testtable (id) VALUES (1);
+		INSERT INTO testtable (id) VALUES (2);`)
+	if err != nil {
+		t.Fatalf("ExecScript error: %v", err)
+	}
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, but was %v: %v", count, err)
+	}
+
+	// A failing statement leaves none of the script applied.
+	err = sdb.ExecScript("INSERT INTO testtable (id) VALUES (3); INSERT INTO testtable (id) VALUES (1);")
+	if err == nil {
+		t.Fatal("ExecScript with a failing statement did not return an error")
+	}
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 2 {
+		t.Errorf("Expected 2 rows, but was %v: %v", count, err)
+	}
+}
+
+func TestPatchDb_VersionQueryError(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	// A version table without a patchid column cannot be queried.
+	if err := sdb.CreateTable("version (id INTEGER)"); err != nil {
+		t.Errorf("CreateTable error: %v", err)
+	}
+
+	patchCalled := false
+	err := sdb.PatchDb([]PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			patchCalled = true
+			return nil
+		}},
+	})
+	if err == nil {
+		t.Error("PatchDb did not return version query error")
+	}
+	if patchCalled {
+		t.Error("Patch function called after version query error")
+	}
+}
+
+func TestOpenAndPatchDb_Concurrent(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	const handles = 8
+	const gkeysPerHandle = 10
+	var wg sync.WaitGroup
+	gkeys := make(chan int, handles*gkeysPerHandle)
+	errs := make(chan error, handles*gkeysPerHandle)
+	for i := 0; i < handles; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sdb, err := OpenAndPatchDb(testDbName, nil)
+			if sdb != nil {
+				defer sdb.Close()
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			for j := 0; j < gkeysPerHandle; j++ {
+				gkey, err := sdb.GetGkey()
+				if err != nil {
+					errs <- err
+					return
+				}
+				gkeys <- gkey
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(gkeys)
+
+	for err := range errs {
+		t.Errorf("Concurrent open error: %v", err)
+	}
+	seen := make(map[int]bool)
+	for gkey := range gkeys {
+		if seen[gkey] {
+			t.Errorf("Duplicate gkey %v", gkey)
+		}
+		seen[gkey] = true
+	}
+}
+
+// The benchmarks skip syncing to disk, so they measure the wrapper rather than the disk.
+const benchDbName = "file:" + testDbName + "?_journal_mode=WAL&_sync=OFF"
+
+func openBenchDb(b *testing.B) *SQLDb {
+	sdb, err := OpenAndPatchDb(benchDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, field1 TEXT)")
+		}},
+	})
+	if err != nil {
+		b.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	return sdb
+}
+
+func BenchmarkExec(b *testing.B) {
+	setupTests(b)
+	defer cleanupTests(b)
+
+	sdb := openBenchDb(b)
+	defer closeDb(b, &sdb)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sdb.Exec("INSERT INTO testtable (field1) VALUES (?)", "a"); err != nil {
+			b.Fatalf("Exec error: %v", err)
+		}
+	}
+}
+
+func BenchmarkExecNoArgs(b *testing.B) {
+	setupTests(b)
+	defer cleanupTests(b)
+
+	sdb := openBenchDb(b)
+	defer closeDb(b, &sdb)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sdb.Exec("PRAGMA cache_size = -2000"); err != nil {
+			b.Fatalf("Exec error: %v", err)
+		}
+	}
+}
+
+func BenchmarkSingleQuery(b *testing.B) {
+	setupTests(b)
+	defer cleanupTests(b)
+
+	sdb := openBenchDb(b)
+	defer closeDb(b, &sdb)
+	if err := sdb.Exec("INSERT INTO testtable (id, field1) VALUES (1, 'a')"); err != nil {
+		b.Fatalf("Exec error: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var field1 string
+		if err := sdb.SingleQueryArgs("SELECT field1 FROM testtable WHERE id = ?", []interface{}{1}, &field1); err != nil {
+			b.Fatalf("SingleQuery error: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetGkey(b *testing.B) {
+	setupTests(b)
+	defer cleanupTests(b)
+
+	sdb := openBenchDb(b)
+	defer closeDb(b, &sdb)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := sdb.GetGkey(); err != nil {
+			b.Fatalf("GetGkey error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !minimal
+
+package sqldbtest
+
+import (
+	"os"
+	"testing"
+
+	sqldb "github.com/semog/go-sqldb"
+)
+
+// LoadCSVFixture - Import the rows of the CSV file, which has a header row of column names,
+// into the table, and fail the test if the import fails.
+func LoadCSVFixture(t testing.TB, sdb *sqldb.SQLDb, table, path string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("sqldbtest: reading fixture: %v", err)
+	}
+	defer f.Close()
+	if err := sdb.ImportTable(f, table, sqldb.FormatCSV); err != nil {
+		t.Fatalf("sqldbtest: loading fixture %s: %v", path, err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !minimal
+
+package sqldbtest
+
+import (
+	"testing"
+)
+
+func TestLoadCSVFixture(t *testing.T) {
+	sdb := NewTempDb(t)
+	MustExec(t, sdb, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)")
+	LoadCSVFixture(t, sdb, "users", "testdata/users.csv")
+	AssertRowCount(t, sdb, "users", 3)
+
+	var name string
+	if err := sdb.Select("name").From("users").Where("id = ?", 4).Single(&name); err != nil || name != "dave" {
+		t.Errorf("Expected dave, but was %s: %v", name, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Package sqldbtest provides helpers for testing code that uses sqldb databases.
+package sqldbtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sqldb "github.com/semog/go-sqldb"
+)
+
+// NewTempDb - Open a new, patched database in a temporary directory. The database is closed
+// and removed when the test finishes.
+func NewTempDb(t testing.TB, opts ...sqldb.Option) *sqldb.SQLDb {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	sdb, err := sqldb.OpenAndPatchDb(path, nil, opts...)
+	if err != nil {
+		t.Fatalf("sqldbtest: opening %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		if err := sdb.Close(); err != nil {
+			t.Errorf("sqldbtest: closing %s: %v", path, err)
+		}
+	})
+	return sdb
+}
+
+// MustExec - Execute the statement with the bound arguments, and fail the test if it fails.
+func MustExec(t testing.TB, sdb sqldb.DBExecutor, stmt string, args ...interface{}) {
+	t.Helper()
+	if err := sdb.Exec(stmt, args...); err != nil {
+		t.Fatalf("sqldbtest: %v", err)
+	}
+}
+
+// LoadSQLFixture - Execute the SQL statements in the file, and fail the test if any fail.
+// Either all of the statements are applied, or none are.
+func LoadSQLFixture(t testing.TB, sdb sqldb.DBExecutor, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("sqldbtest: reading fixture: %v", err)
+	}
+	if err := sdb.ExecScript(string(data)); err != nil {
+		t.Fatalf("sqldbtest: loading fixture %s: %v", path, err)
+	}
+}
+
+// AssertRowCount - Fail the test if the table does not have the expected number of rows.
+func AssertRowCount(t testing.TB, sdb sqldb.DBExecutor, table string, expected int) {
+	t.Helper()
+	var count int
+	if err := sdb.SingleQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s", table), &count); err != nil {
+		t.Fatalf("sqldbtest: counting rows of %s: %v", table, err)
+	}
+	if count != expected {
+		t.Errorf("sqldbtest: expected %d rows in %s, but was %d", expected, table, count)
+	}
+}
@@ -0,0 +1,46 @@
+package sqldbtest
+
+import (
+	"os"
+	"testing"
+
+	sqldb "github.com/semog/go-sqldb"
+)
+
+func TestNewTempDb(t *testing.T) {
+	var path string
+	t.Run("open", func(t *testing.T) {
+		sdb := NewTempDb(t)
+		exists, err := sdb.TableExists("version")
+		if err != nil || !exists {
+			t.Errorf("Expected patched database: %v, %v", exists, err)
+		}
+		path = t.TempDir()
+	})
+	// The temporary directories are removed when the test finishes.
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary directory to be removed: %v", err)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	sdb := NewTempDb(t, sqldb.WithRecoverPanics())
+	LoadSQLFixture(t, sdb, "testdata/users.sql")
+	AssertRowCount(t, sdb, "users", 2)
+
+	MustExec(t, sdb, "DELETE FROM users WHERE name = ?", "bob")
+	AssertRowCount(t, sdb, "users", 1)
+}
+
+func TestFixtures_InTx(t *testing.T) {
+	sdb := NewTempDb(t)
+	err := sdb.InTx(func(tx *sqldb.SQLTx) error {
+		LoadSQLFixture(t, tx, "testdata/users.sql")
+		AssertRowCount(t, tx, "users", 2)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InTx error: %v", err)
+	}
+	AssertRowCount(t, sdb, "users", 2)
+}
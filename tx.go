@@ -0,0 +1,101 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrCloseInTx is returned when closing the database is attempted through a transaction handle.
+var ErrCloseInTx = errors.New("dberror: cannot close the database from a transaction")
+
+// SQLTx - A database handle bound to one connection while it has a transaction open, so that
+// every statement run through it is part of the transaction. Functions run with InTx, and
+// patch functions created with TxPatch, are passed one.
+type SQLTx struct {
+	*SQLDb
+}
+
+var _ DBExecutor = (*SQLTx)(nil)
+
+// TxPatch - Return a patch that runs the function with the patch transaction.
+func TxPatch(patchID int, fn func(tx *SQLTx) error) PatchFuncType {
+	return PatchFuncType{
+		PatchID: patchID,
+		// Patch functions are passed a handle bound to the patch transaction.
+		PatchFunc: func(sdb *SQLDb) error {
+			return fn(&SQLTx{SQLDb: sdb})
+		},
+	}
+}
+
+// InTx - Execute the function inside a transaction on a connection of its own, which is
+// committed if it returns nil and rolled back otherwise. Every statement must be run through
+// the handle the function is passed. In single writer mode, the transaction is one job of the
+// writer, so writes from other goroutines wait until it ends. Called through a handle that
+// already has a transaction open, the function runs inside a save point of it instead.
+func (sdb *SQLDb) InTx(fn func(tx *SQLTx) error) error {
+	run := func(tx *SQLDb) error {
+		if tx.activity.inTransaction(tx.txn) {
+			return tx.NestedTxn(func() error { return fn(&SQLTx{SQLDb: tx}) })
+		}
+		return tx.transaction(func() error { return fn(&SQLTx{SQLDb: tx}) })
+	}
+	if sdb.conn == nil && sdb.activity.inTransaction(sdb.txn) {
+		return run(sdb)
+	}
+	return sdb.dedicated(run)
+}
+
+// Close - Refuse to close the database while the transaction is open. Returns ErrCloseInTx.
+func (tx *SQLTx) Close() error {
+	return ErrCloseInTx
+}
+
+// sqlRunner is where statements are run: the connection pool, or a dedicated connection.
+type sqlRunner interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// runner returns the dedicated connection of a handle bound to one, or else the pool.
+func (sdb *SQLDb) runner() sqlRunner {
+	if sdb.conn != nil {
+		return sdb.conn
+	}
+	return sdb.DB
+}
+
+// withConn returns a handle to the same database that runs every statement on the connection.
+// The handle keeps its own transaction state, apart from the handle it was made from.
+func (sdb *SQLDb) withConn(conn *sql.Conn) *SQLDb {
+	bound := *sdb
+	bound.conn = conn
+	bound.txn = &txState{}
+	bound.savePoints = &savePointStack{}
+	if sdb.scans != nil {
+		bound.scans = sdb.scans.forConn()
+	}
+	return &bound
+}
+
+// connInTransaction reports whether the connection of a bound handle has a transaction open.
+func (sdb *SQLDb) connInTransaction() (bool, error) {
+	var inTx bool
+	err := sdb.conn.Raw(func(driverConn interface{}) error {
+		inTx = !sqliteConn(driverConn).AutoCommit()
+		return nil
+	})
+	return inTx, err
+}
+
+// txnAborted reports whether SQLite has rolled back the transaction of a bound handle by itself,
+// such as for a statement with ON CONFLICT ROLLBACK, which leaves no save points to roll back to.
+func (sdb *SQLDb) txnAborted() bool {
+	if sdb.conn == nil {
+		return false
+	}
+	inTx, err := sdb.connInTransaction()
+	return err == nil && !inTx
+}
@@ -0,0 +1,85 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestTxPatch(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenAndPatchDb(testNoWaitDbName, []PatchFuncType{
+		{PatchID: 1, PatchFunc: func(sdb *SQLDb) error {
+			return sdb.CreateTable("testtable (id INTEGER)")
+		}},
+		TxPatch(2, func(tx *SQLTx) error {
+			if err := tx.Close(); !errors.Is(err, ErrCloseInTx) {
+				t.Errorf("Expected ErrCloseInTx, but was %v", err)
+			}
+			if err := tx.Exec("INSERT INTO testtable (id) VALUES (1), (2)"); err != nil {
+				return err
+			}
+			// Writing while reading only works when both are on the connection
+			// that holds the patch transaction's write lock.
+			return tx.MultiQuery("SELECT id FROM testtable WHERE id < 10", func(rows *sql.Rows) error {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				return tx.Exec("INSERT INTO testtable (id) VALUES (?)", id+10)
+			})
+		}),
+	})
+	if err != nil {
+		t.Fatalf("OpenAndPatchDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	var count int
+	if err := sdb.SingleQuery("SELECT COUNT(*) FROM testtable", &count); err != nil || count != 4 {
+		t.Errorf("Expected 4 rows, but was %v: %v", count, err)
+	}
+	if patched, err := sdb.patched(2); err != nil || !patched {
+		t.Errorf("Expected patch 2 to be applied: %v, %v", patched, err)
+	}
+}
+
+func TestWithConn_OwnTransactionState(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testDbName, WithSingleWriter())
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	err = sdb.PatchDb([]PatchFuncType{
+		{PatchID: 1, PatchFunc: func(tx *SQLDb) error {
+			if !tx.activity.inTransaction(tx.txn) {
+				t.Error("Expected the patch handle to be in a transaction")
+			}
+			if sdb.activity.inTransaction(sdb.txn) {
+				t.Error("Expected the outer handle not to be in the patch transaction")
+			}
+			// Other goroutines querying through the outer handle stay off the patch connection.
+			if sdb.runner() != sqlRunner(sdb.DB) {
+				t.Error("Expected the outer handle to query the pool")
+			}
+			if sdb.activity.idle() {
+				t.Error("Expected the patch transaction to keep the database from draining")
+			}
+			return tx.NestedTxn(func() error {
+				return tx.CreateTable("testtable (id INTEGER)")
+			})
+		}},
+	})
+	if err != nil {
+		t.Fatalf("PatchDb error: %v", err)
+	}
+	if !sdb.activity.idle() {
+		t.Error("Expected the database to be idle after patching")
+	}
+}
@@ -0,0 +1,50 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrStaleRow is returned when an optimistic update finds the row changed since it was read.
+var ErrStaleRow = errors.New("dberror: row was changed or deleted since it was read")
+
+// UpdateIfVersion - Update the columns of the row of the table matched by the key where clause,
+// with its bound arguments, only if its version column still holds the expected version, and
+// increment the version. Returns ErrStaleRow if no row was updated, because another writer
+// changed the version or the row no longer exists.
+func (sdb *SQLDb) UpdateIfVersion(table string, setCols map[string]interface{}, keyWhere string,
+	versionCol string, expectedVersion int64, keyArgs ...interface{}) error {
+	// Sort the columns so the same values always generate the same statement.
+	columns := make([]string, 0, len(setCols))
+	for col := range setCols {
+		if col == versionCol {
+			return fmt.Errorf("dberror: version column %s of %s is set by the update", col, table)
+		}
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	sets := make([]string, 0, len(columns)+1)
+	args := make([]interface{}, 0, len(columns)+len(keyArgs)+1)
+	for _, col := range columns {
+		sets = append(sets, col+" = ?")
+		args = append(args, setCols[col])
+	}
+	sets = append(sets, fmt.Sprintf("%s = %s + 1", versionCol, versionCol))
+	args = append(append(args, keyArgs...), expectedVersion)
+
+	res, err := sdb.ExecResults(fmt.Sprintf("UPDATE %s SET %s WHERE (%s) AND %s = ?",
+		table, strings.Join(sets, ", "), keyWhere, versionCol), args...)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("dberror: could not retrieve the updated row count of %s: %v", table, err)
+	}
+	if updated == 0 {
+		return ErrStaleRow
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqldb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateIfVersion(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, name TEXT, version INTEGER NOT NULL)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id, name, version) VALUES (1, 'a', 1)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	err := sdb.UpdateIfVersion("testtable", map[string]interface{}{"name": "b"}, "id = ?", "version", 1, 1)
+	if err != nil {
+		t.Errorf("UpdateIfVersion error: %v", err)
+	}
+	var name string
+	var version int64
+	if err := sdb.SingleQuery("SELECT name, version FROM testtable WHERE id = 1", &name, &version); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if name != "b" || version != 2 {
+		t.Errorf("Expected (b, 2), but was (%v, %v)", name, version)
+	}
+
+	// A writer still holding version 1 loses.
+	err = sdb.UpdateIfVersion("testtable", map[string]interface{}{"name": "c"}, "id = ?", "version", 1, 1)
+	if !errors.Is(err, ErrStaleRow) {
+		t.Errorf("Expected ErrStaleRow, but was %v", err)
+	}
+	err = sdb.UpdateIfVersion("testtable", map[string]interface{}{"name": "c"}, "id = ?", "version", 2, 2)
+	if !errors.Is(err, ErrStaleRow) {
+		t.Errorf("Expected ErrStaleRow for a missing row, but was %v", err)
+	}
+	err = sdb.UpdateIfVersion("testtable", map[string]interface{}{"version": 5}, "id = ?", "version", 2, 1)
+	if err == nil {
+		t.Error("UpdateIfVersion setting the version column did not return an error")
+	}
+}
@@ -0,0 +1,72 @@
+package sqldb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const uuidSize = 16
+
+// isUUIDType reports whether the type is a 16 byte array, such as [16]byte or uuid.UUID.
+// These are stored as 16 byte blobs rather than their text form, which halves the index size.
+func isUUIDType(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == uuidSize && t.Elem().Kind() == reflect.Uint8
+}
+
+// uuidBlob converts a 16 byte array value to the blob bound to a statement.
+func uuidBlob(v reflect.Value) []byte {
+	b := make([]byte, uuidSize)
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// uuidScanner scans a 16 byte blob into a 16 byte array field.
+type uuidScanner struct {
+	v reflect.Value
+}
+
+func (us uuidScanner) Scan(src interface{}) error {
+	switch b := src.(type) {
+	case nil:
+		us.v.Set(reflect.Zero(us.v.Type()))
+		return nil
+	case []byte:
+		if len(b) != uuidSize {
+			return fmt.Errorf("dberror: cannot scan %d byte blob into %s", len(b), us.v.Type())
+		}
+		reflect.Copy(us.v, reflect.ValueOf(b))
+		return nil
+	}
+	return fmt.Errorf("dberror: cannot scan %T into %s", src, us.v.Type())
+}
+
+// UUIDv7Time - Return the creation time encoded in a time-ordered UUIDv7 key.
+func UUIDv7Time(id [16]byte) time.Time {
+	var ms [8]byte
+	copy(ms[2:], id[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(ms[:])))
+}
+
+// UUIDv7Range - Return the 16 byte blob bounds of all UUIDv7 keys created at or after from,
+// and before to. Keys in the range satisfy lo <= key < hi.
+func UUIDv7Range(from, to time.Time) (lo, hi []byte) {
+	return uuidV7Prefix(from), uuidV7Prefix(to)
+}
+
+// UUIDv7RangeWhere - Return a WHERE clause predicate and its bound arguments selecting the
+// rows whose UUIDv7 blob column was created at or after from, and before to.
+func UUIDv7RangeWhere(column string, from, to time.Time) (string, []interface{}) {
+	lo, hi := UUIDv7Range(from, to)
+	return fmt.Sprintf("%s >= ? AND %s < ?", column, column), []interface{}{lo, hi}
+}
+
+// uuidV7Prefix returns the smallest UUIDv7 blob with the millisecond timestamp of t.
+func uuidV7Prefix(t time.Time) []byte {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	b := make([]byte, uuidSize)
+	copy(b, ms[2:])
+	return b
+}
@@ -0,0 +1,109 @@
+package sqldb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// testUUID mirrors uuid.UUID, which is a named 16 byte array.
+type testUUID [16]byte
+
+type testUUIDRecord struct {
+	ID       testUUID
+	ParentID [16]byte
+	Name     string
+}
+
+func testUUIDv7(t time.Time, seq byte) testUUID {
+	var id testUUID
+	copy(id[:], uuidV7Prefix(t))
+	id[6] = 0x70
+	id[15] = seq
+	return id
+}
+
+func TestUUIDBlobFields(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id BLOB PRIMARY KEY, parent_id BLOB, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	now := time.Now()
+	record := testUUIDRecord{ID: testUUIDv7(now, 1), Name: "first"}
+	if _, err := sdb.InsertStruct("testtable", record); err != nil {
+		t.Fatalf("InsertStruct error: %v", err)
+	}
+
+	var length int
+	if err := sdb.SingleQuery("SELECT length(id) FROM testtable WHERE typeof(id) = 'blob'", &length); err != nil {
+		t.Fatalf("SingleQuery error: %v", err)
+	}
+	if length != 16 {
+		t.Errorf("Expected 16 byte blob key, but was %v", length)
+	}
+
+	var actual testUUIDRecord
+	if err := sdb.QueryStruct(&actual, "SELECT * FROM testtable"); err != nil {
+		t.Fatalf("QueryStruct error: %v", err)
+	}
+	if actual != record {
+		t.Errorf("Expected %v, but was %v", record, actual)
+	}
+
+	// Bound arguments and scan destinations are converted like struct fields.
+	var name string
+	if err := sdb.SingleQueryArgs("SELECT name FROM testtable WHERE id = ?", []interface{}{record.ID}, &name); err != nil || name != "first" {
+		t.Errorf("Expected to look up the row by its key, but was %q: %v", name, err)
+	}
+	var id testUUID
+	if err := sdb.SingleQuery("SELECT id FROM testtable", &id); err != nil || id != record.ID {
+		t.Errorf("Expected key %v, but was %v: %v", record.ID, id, err)
+	}
+	if err := sdb.Exec("UPDATE testtable SET parent_id = ? WHERE id = ?", [16]byte(record.ID), record.ID); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if err := sdb.SingleQuery("SELECT length(parent_id) FROM testtable WHERE typeof(parent_id) = 'blob'", &length); err != nil || length != 16 {
+		t.Errorf("Expected 16 byte blob argument, but was %v: %v", length, err)
+	}
+}
+
+func TestUUIDv7Range(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id BLOB PRIMARY KEY, parent_id BLOB, name TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	start := time.UnixMilli(time.Now().UnixMilli())
+	for i := 0; i < 5; i++ {
+		record := testUUIDRecord{ID: testUUIDv7(start.Add(time.Duration(i)*time.Hour), byte(i)), Name: "record"}
+		if _, err := sdb.InsertStruct("testtable", record); err != nil {
+			t.Fatalf("InsertStruct error: %v", err)
+		}
+	}
+
+	where, args := UUIDv7RangeWhere("id", start.Add(time.Hour), start.Add(3*time.Hour))
+	var records []testUUIDRecord
+	if err := sdb.QueryStructs(&records, "SELECT * FROM testtable WHERE "+where+" ORDER BY id", args...); err != nil {
+		t.Fatalf("QueryStructs error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records in range, but was %v", len(records))
+	}
+	if !UUIDv7Time(records[0].ID).Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected first record at %v, but was %v", start.Add(time.Hour), UUIDv7Time(records[0].ID))
+	}
+
+	lo, hi := UUIDv7Range(start, start.Add(time.Millisecond))
+	if bytes.Compare(lo, hi) >= 0 {
+		t.Error("Expected lower bound to sort before upper bound")
+	}
+}
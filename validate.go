@@ -0,0 +1,207 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The tables created by the internal patches, which are not part of an application schema.
+var internalTables = map[string]bool{
+	"version":          true,
+	"gkey":             true,
+	kvTableName:        true,
+	seedTableName:      true,
+	blobChunkTableName: true,
+}
+
+// The prefix of the names of the other schema objects created by the package, such as triggers.
+const internalPrefix = "_sqldb_"
+
+// isInternalObject reports whether the schema object of the type was created by the package.
+func isInternalObject(objType, name string) bool {
+	name = strings.ToLower(name)
+	return (objType == "table" && internalTables[name]) || strings.HasPrefix(name, internalPrefix)
+}
+
+// Schema - The expected definition of the application tables in the database.
+type Schema struct {
+	Tables []TableSchema
+}
+
+// TableSchema - The expected definition of a table.
+type TableSchema struct {
+	Name    string
+	Columns []ColumnSchema
+	// Indexes are the names of the indexes expected on the table. Indexes created
+	// automatically for UNIQUE and PRIMARY KEY constraints are not included.
+	Indexes []string
+}
+
+// ColumnSchema - The expected definition of a table column.
+type ColumnSchema struct {
+	Name string
+	// Type is the declared type of the column, compared without regard to case.
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+}
+
+// DriftKind - The kind of difference between the live database and the expected schema.
+type DriftKind int
+
+const (
+	// DriftMissingTable is an expected table that does not exist.
+	DriftMissingTable DriftKind = iota
+	// DriftExtraTable is a table that exists but is not expected.
+	DriftExtraTable
+	// DriftMissingColumn is an expected column that does not exist.
+	DriftMissingColumn
+	// DriftExtraColumn is a column that exists but is not expected.
+	DriftExtraColumn
+	// DriftColumnType is a column with a different declared type than expected.
+	DriftColumnType
+	// DriftColumnNotNull is a column whose NOT NULL constraint differs from expected.
+	DriftColumnNotNull
+	// DriftColumnPrimaryKey is a column whose primary key membership differs from expected.
+	DriftColumnPrimaryKey
+	// DriftMissingIndex is an expected index that does not exist.
+	DriftMissingIndex
+	// DriftExtraIndex is an index that exists but is not expected.
+	DriftExtraIndex
+)
+
+var driftKindNames = []string{
+	"missing table",
+	"extra table",
+	"missing column",
+	"extra column",
+	"column type",
+	"column not null",
+	"column primary key",
+	"missing index",
+	"extra index",
+}
+
+func (k DriftKind) String() string {
+	if k >= 0 && int(k) < len(driftKindNames) {
+		return driftKindNames[k]
+	}
+	return fmt.Sprintf("DriftKind(%d)", int(k))
+}
+
+// Drift - A difference between the live database and the expected schema.
+type Drift struct {
+	Kind  DriftKind
+	Table string
+	// Name is the column or index name, for column and index drift.
+	Name string
+	// Expected and Actual describe the differing definitions, for column definition drift.
+	Expected string
+	Actual   string
+}
+
+func (d Drift) String() string {
+	target := d.Table
+	if d.Name != "" {
+		target += "." + d.Name
+	}
+	if d.Expected != "" || d.Actual != "" {
+		return fmt.Sprintf("%s %s: expected %s, but was %s", d.Kind, target, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("%s %s", d.Kind, target)
+}
+
+// ValidateSchema - Compare the live database against the expected schema, and report every
+// difference. Useful for detecting databases that have been modified by external tools.
+// The tables created by the internal patches are ignored.
+func (sdb *SQLDb) ValidateSchema(expected Schema) ([]Drift, error) {
+	var drifts []Drift
+	tables, err := sdb.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		live[strings.ToLower(table)] = true
+	}
+
+	wanted := make(map[string]bool, len(expected.Tables))
+	for _, ts := range expected.Tables {
+		wanted[strings.ToLower(ts.Name)] = true
+		if !live[strings.ToLower(ts.Name)] {
+			drifts = append(drifts, Drift{Kind: DriftMissingTable, Table: ts.Name})
+			continue
+		}
+		tableDrifts, err := sdb.validateTable(ts)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, tableDrifts...)
+	}
+	for _, table := range tables {
+		if !wanted[strings.ToLower(table)] {
+			drifts = append(drifts, Drift{Kind: DriftExtraTable, Table: table})
+		}
+	}
+	return drifts, nil
+}
+
+func (sdb *SQLDb) validateTable(ts TableSchema) ([]Drift, error) {
+	var drifts []Drift
+	columns, err := sdb.TableInfo(ts.Name)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]ColumnInfo, len(columns))
+	for _, col := range columns {
+		live[strings.ToLower(col.Name)] = col
+	}
+	wanted := make(map[string]bool, len(ts.Columns))
+	for _, cs := range ts.Columns {
+		wanted[strings.ToLower(cs.Name)] = true
+		col, ok := live[strings.ToLower(cs.Name)]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: DriftMissingColumn, Table: ts.Name, Name: cs.Name})
+			continue
+		}
+		if !strings.EqualFold(col.Type, cs.Type) {
+			drifts = append(drifts, Drift{Kind: DriftColumnType, Table: ts.Name, Name: cs.Name,
+				Expected: cs.Type, Actual: col.Type})
+		}
+		if col.NotNull != cs.NotNull {
+			drifts = append(drifts, Drift{Kind: DriftColumnNotNull, Table: ts.Name, Name: cs.Name,
+				Expected: fmt.Sprint(cs.NotNull), Actual: fmt.Sprint(col.NotNull)})
+		}
+		if (col.PrimaryKey > 0) != cs.PrimaryKey {
+			drifts = append(drifts, Drift{Kind: DriftColumnPrimaryKey, Table: ts.Name, Name: cs.Name,
+				Expected: fmt.Sprint(cs.PrimaryKey), Actual: fmt.Sprint(col.PrimaryKey > 0)})
+		}
+	}
+	for _, col := range columns {
+		if !wanted[strings.ToLower(col.Name)] {
+			drifts = append(drifts, Drift{Kind: DriftExtraColumn, Table: ts.Name, Name: col.Name})
+		}
+	}
+
+	indexes, err := sdb.queryNames("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL ORDER BY name", ts.Name)
+	if err != nil {
+		return nil, err
+	}
+	liveIndexes := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		liveIndexes[strings.ToLower(index)] = true
+	}
+	wantedIndexes := make(map[string]bool, len(ts.Indexes))
+	for _, index := range ts.Indexes {
+		wantedIndexes[strings.ToLower(index)] = true
+		if !liveIndexes[strings.ToLower(index)] {
+			drifts = append(drifts, Drift{Kind: DriftMissingIndex, Table: ts.Name, Name: index})
+		}
+	}
+	for _, index := range indexes {
+		if !wantedIndexes[strings.ToLower(index)] {
+			drifts = append(drifts, Drift{Kind: DriftExtraIndex, Table: ts.Name, Name: index})
+		}
+	}
+	return drifts, nil
+}
@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testExpectedSchema = Schema{Tables: []TableSchema{
+	{
+		Name: "testtable",
+		Columns: []ColumnSchema{
+			{Name: "id", Type: "INTEGER", PrimaryKey: true},
+			{Name: "field1", Type: "text", NotNull: true},
+		},
+		Indexes: []string{"test_idx"},
+	},
+}}
+
+func TestValidateSchema(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER PRIMARY KEY, field1 TEXT NOT NULL)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("test_idx ON testtable (field1)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+
+	drifts, err := sdb.ValidateSchema(testExpectedSchema)
+	if err != nil {
+		t.Fatalf("ValidateSchema error: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("Expected no drift, but was %v", drifts)
+	}
+}
+
+func TestValidateSchema_Drift(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb := openPatchedTestDb(t)
+	defer closeDb(t, &sdb)
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 BLOB, field2 TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.CreateIndex("other_idx ON testtable (field2)"); err != nil {
+		t.Fatalf("CreateIndex error: %v", err)
+	}
+	if err := sdb.CreateTable("othertable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+
+	schema := testExpectedSchema
+	schema.Tables = append(schema.Tables, TableSchema{Name: "missingtable"})
+	drifts, err := sdb.ValidateSchema(schema)
+	if err != nil {
+		t.Fatalf("ValidateSchema error: %v", err)
+	}
+	expected := []Drift{
+		{Kind: DriftColumnPrimaryKey, Table: "testtable", Name: "id", Expected: "true", Actual: "false"},
+		{Kind: DriftColumnType, Table: "testtable", Name: "field1", Expected: "text", Actual: "BLOB"},
+		{Kind: DriftColumnNotNull, Table: "testtable", Name: "field1", Expected: "true", Actual: "false"},
+		{Kind: DriftExtraColumn, Table: "testtable", Name: "field2"},
+		{Kind: DriftMissingIndex, Table: "testtable", Name: "test_idx"},
+		{Kind: DriftExtraIndex, Table: "testtable", Name: "other_idx"},
+		{Kind: DriftMissingTable, Table: "missingtable"},
+		{Kind: DriftExtraTable, Table: "othertable"},
+	}
+	if !reflect.DeepEqual(drifts, expected) {
+		t.Errorf("Expected drift:\n%v\nbut was:\n%v", expected, drifts)
+	}
+	if s := drifts[1].String(); s != "column type testtable.field1: expected text, but was BLOB" {
+		t.Errorf("Unexpected drift description: %s", s)
+	}
+}
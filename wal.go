@@ -0,0 +1,150 @@
+package sqldb
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Each WAL frame is a page of data plus a 24 byte frame header.
+const walFrameHeaderSize = 24
+
+// WalStats - Write-ahead log activity observed on this database handle.
+type WalStats struct {
+	// WalSize is the current size in bytes of the -wal file.
+	WalSize int64
+	// PeakWalSize is the largest -wal file size observed.
+	PeakWalSize int64
+	// Commits is the number of transactions committed through this handle.
+	Commits int64
+	// Checkpoints is the number of checkpoints completed by WalCheckpoint, including those
+	// run by WithAutoCheckpoint and Shutdown. SQLite's own automatic checkpoints are not
+	// counted, since the driver does not expose the WAL hook that reports them.
+	Checkpoints int64
+	// LastCheckpoint is when the last counted checkpoint completed.
+	LastCheckpoint time.Time
+	// PagesWritten is the number of pages appended to the -wal file.
+	PagesWritten int64
+}
+
+// PagesPerCommit - Average number of pages appended to the WAL by each commit.
+func (ws WalStats) PagesPerCommit() float64 {
+	if ws.Commits == 0 {
+		return 0
+	}
+	return float64(ws.PagesWritten) / float64(ws.Commits)
+}
+
+// WithWalWarning - Call fn whenever the -wal file grows beyond maxBytes, which indicates
+// that checkpoints are not keeping up with the write load. The function is called once
+// each time the threshold is crossed, and again only after the WAL has shrunk back below it.
+func WithWalWarning(maxBytes int64, fn func(stats WalStats)) Option {
+	return func(sdb *SQLDb) {
+		sdb.wal.warnSize = maxBytes
+		sdb.wal.warnFunc = fn
+	}
+}
+
+// WalStats - Return the WAL statistics for the database.
+func (sdb *SQLDb) WalStats() WalStats {
+	sdb.wal.observe()
+	return sdb.wal.snapshot()
+}
+
+type walMonitor struct {
+	mu        sync.Mutex
+	walPath   string
+	frameSize int64
+	stats     WalStats
+	warnSize  int64
+	warnFunc  func(stats WalStats)
+	warned    bool
+}
+
+func newWalMonitor(dbFilename string) *walMonitor {
+	return &walMonitor{walPath: walFilePath(dbFilename)}
+}
+
+// walFilePath returns the path of the -wal file for the database DSN, or an empty
+// string for in-memory databases.
+func walFilePath(dbFilename string) string {
+	path := dbFilePath(dbFilename)
+	if path == "" {
+		return ""
+	}
+	return path + "-wal"
+}
+
+func (wm *walMonitor) init(sdb *SQLDb) error {
+	var pageSize int64
+	if err := sdb.SingleQuery("PRAGMA page_size", &pageSize); err != nil {
+		return err
+	}
+	wm.mu.Lock()
+	wm.frameSize = pageSize + walFrameHeaderSize
+	wm.mu.Unlock()
+	wm.observe()
+	return nil
+}
+
+func (wm *walMonitor) commit() {
+	wm.mu.Lock()
+	wm.stats.Commits++
+	wm.mu.Unlock()
+}
+
+func (wm *walMonitor) snapshot() WalStats {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	return wm.stats
+}
+
+// checkpointed records a completed checkpoint, and samples the size of the -wal file.
+func (wm *walMonitor) checkpointed() {
+	var size int64
+	if wm.walPath != "" {
+		if fi, err := os.Stat(wm.walPath); err == nil {
+			size = fi.Size()
+		}
+	}
+	wm.mu.Lock()
+	wm.stats.Checkpoints++
+	wm.stats.LastCheckpoint = time.Now()
+	wm.stats.WalSize = size
+	wm.mu.Unlock()
+}
+
+// observe samples the size of the -wal file and raises the warning callback if needed.
+func (wm *walMonitor) observe() {
+	if wm.walPath == "" {
+		return
+	}
+	var size int64
+	if fi, err := os.Stat(wm.walPath); err == nil {
+		size = fi.Size()
+	}
+
+	wm.mu.Lock()
+	if size > wm.stats.WalSize && wm.frameSize > 0 {
+		wm.stats.PagesWritten += (size - wm.stats.WalSize) / wm.frameSize
+	}
+	wm.stats.WalSize = size
+	if size > wm.stats.PeakWalSize {
+		wm.stats.PeakWalSize = size
+	}
+	var warn func(stats WalStats)
+	if wm.warnFunc != nil && wm.warnSize > 0 {
+		if size > wm.warnSize && !wm.warned {
+			wm.warned = true
+			warn = wm.warnFunc
+		} else if size <= wm.warnSize {
+			wm.warned = false
+		}
+	}
+	stats := wm.stats
+	wm.mu.Unlock()
+
+	if warn != nil {
+		warn(stats)
+	}
+}
@@ -0,0 +1,95 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+const testWalDbName = "file:" + testDbName + "?_journal_mode=WAL"
+
+func TestWalStats(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	sdb, err := OpenDb(testWalDbName)
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if err := sdb.CreateTable("testtable (id INTEGER, field1 TEXT)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := sdb.Exec("INSERT INTO testtable (id, field1) VALUES (?, ?)", i, "value"); err != nil {
+			t.Fatalf("Insert error: %v", err)
+		}
+	}
+
+	stats := sdb.WalStats()
+	if stats.WalSize == 0 {
+		t.Error("Expected WAL file to have grown")
+	}
+	if stats.Commits < 11 {
+		t.Errorf("Expected at least 11 commits, but was %v", stats.Commits)
+	}
+	if stats.PagesWritten == 0 {
+		t.Error("Expected pages to be written to the WAL")
+	}
+	if stats.PagesPerCommit() <= 0 {
+		t.Error("Expected positive pages per commit")
+	}
+	if stats.Checkpoints != 0 {
+		t.Errorf("Expected no checkpoints, but was %v", stats.Checkpoints)
+	}
+
+	// A checkpoint that does not shrink the WAL is counted too.
+	if _, err := sdb.WalCheckpoint(CheckpointPassive); err != nil {
+		t.Fatalf("WalCheckpoint error: %v", err)
+	}
+	if _, err := sdb.WalCheckpoint(CheckpointTruncate); err != nil {
+		t.Fatalf("WalCheckpoint error: %v", err)
+	}
+	stats = sdb.WalStats()
+	if stats.Checkpoints != 2 {
+		t.Errorf("Expected 2 checkpoints, but was %v", stats.Checkpoints)
+	}
+	if stats.PeakWalSize <= stats.WalSize {
+		t.Error("Expected peak WAL size to exceed truncated size")
+	}
+}
+
+func TestWalWarning(t *testing.T) {
+	setupTests(t)
+	defer cleanupTests(t)
+
+	warnings := 0
+	sdb, err := OpenDb(testWalDbName, WithWalWarning(1, func(stats WalStats) {
+		warnings++
+	}))
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if err := sdb.CreateTable("testtable (id INTEGER)"); err != nil {
+		t.Fatalf("CreateTable error: %v", err)
+	}
+	if err := sdb.Exec("INSERT INTO testtable (id) VALUES (1)"); err != nil {
+		t.Fatalf("Insert error: %v", err)
+	}
+	if warnings != 1 {
+		t.Errorf("Expected 1 WAL warning, but was %v", warnings)
+	}
+}
+
+func TestWalStatsMemoryDb(t *testing.T) {
+	sdb, err := OpenDb(":memory:")
+	if err != nil {
+		t.Fatalf("OpenDb error: %v", err)
+	}
+	defer closeDb(t, &sdb)
+
+	if stats := sdb.WalStats(); stats.WalSize != 0 {
+		t.Errorf("Expected no WAL for memory database, but was %v", stats.WalSize)
+	}
+}